@@ -0,0 +1,151 @@
+package sequence
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// NotePosition places a note relative to the participants it annotates
+type NotePosition string
+
+const (
+	NoteLeftOf  NotePosition = "left of"
+	NoteRightOf NotePosition = "right of"
+	NoteOver    NotePosition = "over"
+)
+
+type (
+	// Note annotates the diagram with text beside or over one or more participants, such as "cache miss"
+	// or "retry #2". Notes and dividers are shown as unnumbered rows of the log table, so the arrows are
+	// numbered in sequence and each matches the numbered row of its payload
+	Note struct {
+		// Position defaults to NoteOver
+		Position NotePosition
+		// Participants the note is attached to. Notes over several participants span from the first to
+		// the last, notes beside several participants are drawn beside the first or the last
+		Participants []string
+		Text         string
+	}
+
+	// Divider splits the diagram into sections, such as "phase: checkout". It is drawn across every
+	// participant
+	Divider struct {
+		Text string
+	}
+)
+
+func (r *Diagram) AddNote(note Note) *Diagram {
+	return r.AddEvent(note)
+}
+
+func (r *Diagram) AddDivider(divider Divider) *Diagram {
+	return r.AddEvent(divider)
+}
+
+func (r Note) From() string {
+	if len(r.Participants) == 0 {
+		return ""
+	}
+	return r.Participants[0]
+}
+
+func (r Note) To() string {
+	if len(r.Participants) == 0 {
+		return ""
+	}
+	return r.Participants[len(r.Participants)-1]
+}
+
+func (r Note) IsResponse() bool { return false }
+
+func (r Note) Label() string { return r.Text }
+
+func (r Note) LogEntry() (LogEntry, error) {
+	return LogEntry{
+		Header:     r.Text,
+		Annotation: fmt.Sprintf("Note %s %s", r.position(), strings.Join(r.Participants, ", ")),
+	}, nil
+}
+
+func (r Note) position() NotePosition {
+	if r.Position == "" {
+		return NoteOver
+	}
+	return r.Position
+}
+
+// span returns the participants a note is drawn against: the first or last participant for notes
+// beside them, and the first and last for notes over them
+func (r Note) span() []string {
+	first, last := r.From(), r.To()
+	switch {
+	case r.position() == NoteLeftOf || first == last:
+		return []string{first}
+	case r.position() == NoteRightOf:
+		return []string{last}
+	}
+	return []string{first, last}
+}
+
+func (r Note) validate() error {
+	switch r.position() {
+	case NoteLeftOf, NoteRightOf, NoteOver:
+	default:
+		return fmt.Errorf("note has unknown position %q", r.Position)
+	}
+	if len(r.Participants) == 0 {
+		return fmt.Errorf("note has no participants")
+	}
+	return nil
+}
+
+func (r Divider) From() string { return "" }
+
+func (r Divider) To() string { return "" }
+
+func (r Divider) IsResponse() bool { return false }
+
+func (r Divider) Label() string { return r.Text }
+
+func (r Divider) LogEntry() (LogEntry, error) {
+	return LogEntry{Header: r.Text, Annotation: "Divider"}, nil
+}
+
+// isAnnotation reports whether the event is a note or divider rather than an arrow between participants
+func isAnnotation(event Event) bool {
+	switch event.(type) {
+	case Note, Divider, GroupStart, GroupBranch, GroupEnd:
+		return true
+	}
+	return false
+}
+
+// dslRows holds the rows of a text based diagram. Rows drawn across every participant, such as dividers,
+// are written once the rows are complete and every participant is known
+type dslRows struct {
+	rows     []string
+	spanning map[int]string
+}
+
+func (r *dslRows) add(row string) {
+	r.rows = append(r.rows, row)
+}
+
+func (r *dslRows) addSpanning(text string) {
+	if r.spanning == nil {
+		r.spanning = map[int]string{}
+	}
+	r.spanning[len(r.rows)] = text
+	r.rows = append(r.rows, "")
+}
+
+// write writes each row, using spanning to write the rows drawn across every participant
+func (r *dslRows) write(out *bytes.Buffer, spanning func(text string) string) {
+	for i, row := range r.rows {
+		if text, ok := r.spanning[i]; ok {
+			row = spanning(text)
+		}
+		out.WriteString(row)
+	}
+}
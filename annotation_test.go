@@ -0,0 +1,166 @@
+package sequence
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestDiagram_RenderWebSequenceDSL_DrawsNotesAndDividers(t *testing.T) {
+	dsl, err := anAnnotatedDiagram().RenderWebSequenceDSL()
+
+	assert.Nil(t, err)
+	assert.Equal(t, `Note over cli,db: == phase: checkout ==
+cli->app: (1) GET /cart
+Note right of app: cache miss
+app->db: (2) SELECT
+db->>app: (3) rows
+Note over app,db: retry #2
+app->>cli: (4) 204
+`, dsl)
+}
+
+func TestDiagram_RenderMermaid_DrawsNotesAndDividers(t *testing.T) {
+	mermaid, err := anAnnotatedDiagram().RenderMermaid()
+
+	assert.Nil(t, err)
+	assert.Equal(t, `sequenceDiagram
+    participant cli
+    participant app
+    participant db
+    Note over cli,db: == phase: checkout ==
+    cli->>app: (1) GET /cart
+    Note right of app: cache miss
+    app->>db: (2) SELECT
+    db-->>app: (3) rows
+    Note over app,db: retry #35;2
+    app-->>cli: (4) 204
+`, mermaid)
+}
+
+func TestDiagram_RenderPlantUML_DrawsNotesAndDividers(t *testing.T) {
+	plantUML, err := anAnnotatedDiagram().RenderPlantUML()
+
+	assert.Nil(t, err)
+	assert.Equal(t, `@startuml
+header 204
+participant cli
+participant app
+participant db
+== phase: checkout ==
+cli -> app : (1) GET /cart
+note right of app : cache miss
+app -> db : (2) SELECT
+db --> app : (3) rows
+note over app, db : retry #2
+app --> cli : (4) 204
+@enduml
+`, plantUML)
+}
+
+func TestDiagram_RenderSVG_DrawsNotesAndDividers(t *testing.T) {
+	svg, err := anAnnotatedDiagram().RenderSVG()
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, strings.Count(svg, `<rect class="note"`))
+	assert.Equal(t, 1, strings.Count(svg, `<line class="divider"`))
+	assert.Contains(t, svg, ">phase: checkout</text>")
+	assert.Contains(t, svg, ">cache miss</text>")
+	assert.Contains(t, svg, ">(4) 204</text>")
+}
+
+func TestSVGDiagram_MakesRoomForNotesBesideParticipants(t *testing.T) {
+	svg := SVGDiagram{}
+	svg.AddNote(NoteLeftOf, []string{"A"}, "a note left of A")
+	svg.AddRequestRow("A", "B", "x")
+	svg.AddNote(NoteRightOf, []string{"A"}, "a note right of A")
+
+	columns := svg.layoutColumns()
+
+	for _, row := range svg.rows {
+		if row.note == "" {
+			continue
+		}
+		x, w := row.noteBox(columns)
+		assert.True(t, x >= svgMargin, row.description)
+		if row.note == NoteRightOf {
+			assert.True(t, x+w <= columns["B"], row.description)
+		}
+	}
+}
+
+func TestDiagram_BuildModel_ShowsAnnotatedRows(t *testing.T) {
+	model, err := anAnnotatedDiagram().BuildModel()
+
+	assert.Nil(t, err)
+	assert.Len(t, model.LogEntries, 7)
+	assert.Equal(t, LogEntry{Header: "phase: checkout", Annotation: "Divider"}, model.LogEntries[0])
+	assert.Equal(t, LogEntry{Header: "cache miss", Annotation: "Note right of app"}, model.LogEntries[2])
+	assert.Equal(t, LogEntry{Header: "retry #2", Annotation: "Note over app, db"}, model.LogEntries[5])
+}
+
+func TestDiagram_BuildModel_AllowsAnnotationsAfterTheFinalResponse(t *testing.T) {
+	model, err := aDiagram().AddNote(Note{Participants: []string{"A"}, Text: "done"}).BuildModel()
+
+	assert.Nil(t, err)
+	assert.Equal(t, 204, model.StatusCode)
+}
+
+func TestDiagram_RenderMermaid_ErrorIfNoteIsInvalid(t *testing.T) {
+	_, err := aDiagram().AddNote(Note{Text: "nowhere"}).RenderMermaid()
+	assert.EqualError(t, err, "event 3: note has no participants")
+
+	_, err = aDiagram().AddNote(Note{Position: "under", Participants: []string{"A"}}).RenderMermaid()
+	assert.EqualError(t, err, `event 3: note has unknown position "under"`)
+}
+
+func TestDocument_RenderHTML_ShowsAnnotatedRows(t *testing.T) {
+	html, err := NewDocument().AddDiagram(anAnnotatedDiagram()).RenderHTML()
+
+	assert.Nil(t, err)
+	assert.Contains(t, html, "<td colspan=\"2\"><em>Note right of app:</em> cache miss</td>")
+	assert.Contains(t, html, "<td colspan=\"2\"><em>Divider:</em> phase: checkout</td>")
+}
+
+func TestDocument_RenderHTML_NumbersRowsLikeTheArrows(t *testing.T) {
+	html, err := NewDocument().AddDiagram(anAnnotatedDiagram()).RenderHTML()
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, strings.Count(html, `<th scope="row"></th>`))
+	for _, number := range []string{"1", "2", "3", "4"} {
+		assert.Contains(t, html, `<th scope="row">`+number+`</th>`)
+	}
+	assert.NotContains(t, html, `<th scope="row">5</th>`)
+}
+
+func TestDocument_RenderMarkdown_ShowsAnnotatedRows(t *testing.T) {
+	markdown, err := NewDocument().AddDiagram(anAnnotatedDiagram()).RenderMarkdown()
+
+	assert.Nil(t, err)
+	assert.Contains(t, markdown, "> *Note right of app:* cache miss\n\n**(2)**\n")
+}
+
+func TestDiagram_MarshalJSON_RoundTripsAnnotations(t *testing.T) {
+	data, err := json.Marshal(anAnnotatedDiagram())
+	assert.Nil(t, err)
+
+	var diagram Diagram
+	err = json.Unmarshal(data, &diagram)
+
+	assert.Nil(t, err)
+	assert.Equal(t, Divider{Text: "phase: checkout"}, diagram.Events[0])
+	assert.Equal(t, Note{Position: NoteRightOf, Participants: []string{"app"}, Text: "cache miss"}, diagram.Events[2])
+	assert.Equal(t, Note{Participants: []string{"app", "db"}, Text: "retry #2"}, diagram.Events[5])
+}
+
+func anAnnotatedDiagram() *Diagram {
+	return NewDiagram().
+		AddDivider(Divider{Text: "phase: checkout"}).
+		AddMessageRequest(MessageRequest{Source: "cli", Target: "app", Header: "GET /cart"}).
+		AddNote(Note{Position: NoteRightOf, Participants: []string{"app"}, Text: "cache miss"}).
+		AddMessageRequest(MessageRequest{Source: "app", Target: "db", Header: "SELECT"}).
+		AddMessageResponse(MessageResponse{Source: "db", Target: "app", Header: "rows"}).
+		AddNote(Note{Participants: []string{"app", "db"}, Text: "retry #2"}).
+		AddHttpResponse(HttpResponse{Source: "app", Target: "cli", Value: aResponse().Value})
+}
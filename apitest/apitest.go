@@ -0,0 +1,133 @@
+// Package apitest runs table driven tests against an http.Handler and records each test case as a
+// sequence diagram, including the outbound calls the handler makes to mocked dependencies
+package apitest
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/steinfletcher/sequence-diagrams"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type (
+	// Mocks is the layer that answers outbound calls made by the handler under test. Implement it to
+	// plug in a mocking library such as gock, or use MockTransport
+	Mocks interface {
+		// Transport returns the transport that serves outbound calls
+		Transport() http.RoundTripper
+		// Verify returns an error if an outbound call was not matched or an expected call was not made
+		Verify() error
+		// Reset clears all mocks after a test case
+		Reset()
+	}
+
+	TestCase struct {
+		Name                   string
+		RequestMethod          string
+		RequestURL             string
+		RequestBody            string
+		ExpectedResponseBody   string
+		ExpectedResponseStatus int
+		Before                 func(test *ApiTest)
+	}
+
+	ApiTest struct {
+		Handler      http.Handler
+		Mocks        Mocks
+		Report       *Report
+		ReportWriter *sequence.ReportWriter
+	}
+)
+
+func New(handler http.Handler) *ApiTest {
+	return &ApiTest{Handler: handler}
+}
+
+// WithMocks sets the layer that answers outbound calls
+func (a *ApiTest) WithMocks(mocks Mocks) *ApiTest {
+	a.Mocks = mocks
+	return a
+}
+
+// WithReport adds the diagram of each test case to the report
+func (a *ApiTest) WithReport(report *Report) *ApiTest {
+	a.Report = report
+	return a
+}
+
+// WithReportWriter writes a report for each test case, named after the test, to the writer's directory
+func (a *ApiTest) WithReportWriter(writer *sequence.ReportWriter) *ApiTest {
+	a.ReportWriter = writer
+	return a
+}
+
+// Run runs each test case as a subtest. Outbound calls are recorded by replacing http.DefaultTransport
+// while a test case runs, so test cases using the same ApiTest must not run in parallel
+func (a *ApiTest) Run(t *testing.T, testCases ...TestCase) {
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.Name, func(t *testing.T) {
+			a.run(t, testCase)
+		})
+	}
+}
+
+func (a *ApiTest) run(t *testing.T, testCase TestCase) {
+	diagram := sequence.NewDiagram().
+		AddTitle(fmt.Sprintf("%s %s", testCase.RequestMethod, testCase.RequestURL)).
+		AddSubTitle(testCase.Name)
+
+	if a.Mocks != nil {
+		defer a.Mocks.Reset()
+		original := http.DefaultTransport
+		defer func() { http.DefaultTransport = original }()
+	}
+
+	if testCase.Before != nil {
+		testCase.Before(a)
+	}
+
+	// Before may replace http.DefaultTransport, as gock.New does, so the recorder is installed after it
+	if a.Mocks != nil {
+		http.DefaultTransport = sequence.NewRecordingTransport(diagram).WithTransport(a.Mocks.Transport())
+	}
+
+	res := httptest.NewRecorder()
+	sequence.NewRecordingHandler(diagram, a.Handler).ServeHTTP(res, buildRequest(testCase))
+
+	if a.Mocks != nil {
+		if err := a.Mocks.Verify(); err != nil {
+			t.Error(err)
+		}
+	}
+	assertResponse(t, testCase, res)
+
+	if a.Report != nil {
+		a.Report.AddDiagram(diagram)
+	}
+	if a.ReportWriter != nil {
+		document := sequence.NewDocument().
+			AddTitle(diagram.Title).
+			AddDescription(testCase.Name).
+			AddDiagram(diagram)
+		if _, err := a.ReportWriter.Write(t.Name(), document); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func buildRequest(testCase TestCase) *http.Request {
+	req := httptest.NewRequest(testCase.RequestMethod, testCase.RequestURL, bytes.NewBufferString(testCase.RequestBody))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func assertResponse(t *testing.T, testCase TestCase, res *httptest.ResponseRecorder) {
+	assert.Equal(t, testCase.ExpectedResponseStatus, res.Code, testCase.Name)
+	if testCase.ExpectedResponseBody != "" {
+		assert.JSONEq(t, testCase.ExpectedResponseBody, res.Body.String(), testCase.Name)
+	}
+}
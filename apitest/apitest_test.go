@@ -0,0 +1,143 @@
+package apitest
+
+import (
+	"github.com/steinfletcher/sequence-diagrams"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+func TestApiTest_RecordsTestCaseAsDiagram(t *testing.T) {
+	report := NewReport("Posts API")
+	mocks := NewMockTransport()
+
+	New(postsHandler()).
+		WithMocks(mocks).
+		WithReport(report).
+		Run(t, TestCase{
+			Name:                   "get posts",
+			RequestMethod:          http.MethodGet,
+			RequestURL:             "/post",
+			ExpectedResponseStatus: http.StatusOK,
+			ExpectedResponseBody:   `{"title": "go rulez"}`,
+			Before: func(test *ApiTest) {
+				mocks.Add(Mock{Method: http.MethodGet, URL: "http://example.com/posts", Status: http.StatusOK, Body: `{"title":"go rulez"}`})
+			},
+		})
+
+	assert.Len(t, report.Document.Diagrams, 1)
+	diagram := report.Document.Diagrams[0]
+	assert.Equal(t, "GET /post", diagram.Title)
+	assert.Equal(t, "get posts", diagram.SubTitle)
+	assert.Len(t, diagram.Events, 4)
+	assert.Equal(t, "consumer", diagram.Events[0].From())
+	assert.Equal(t, "example.com", diagram.Events[1].To())
+	assert.Equal(t, "consumer", diagram.Events[3].To())
+}
+
+func TestApiTest_RecordsOutboundCallsWhenBeforeReplacesDefaultTransport(t *testing.T) {
+	report := NewReport("Posts API")
+	mocks := NewMockTransport()
+
+	New(postsHandler()).
+		WithMocks(mocks).
+		WithReport(report).
+		Run(t, TestCase{
+			Name:                   "get posts",
+			RequestMethod:          http.MethodGet,
+			RequestURL:             "/post",
+			ExpectedResponseStatus: http.StatusOK,
+			Before: func(test *ApiTest) {
+				mocks.Add(Mock{Method: http.MethodGet, URL: "http://example.com/posts", Status: http.StatusOK, Body: `{}`})
+				http.DefaultTransport = mocks
+			},
+		})
+
+	diagram := report.Document.Diagrams[0]
+	assert.Len(t, diagram.Events, 4)
+	assert.Equal(t, "example.com", diagram.Events[1].To())
+	assert.Equal(t, "example.com", diagram.Events[2].From())
+}
+
+func TestApiTest_RecordsOutboundResponsesTheHandlerDoesNotRead(t *testing.T) {
+	report := NewReport("Posts API")
+	mocks := NewMockTransport()
+
+	New(postsHandler()).
+		WithMocks(mocks).
+		WithReport(report).
+		Run(t, TestCase{
+			Name:                   "delete post",
+			RequestMethod:          http.MethodDelete,
+			RequestURL:             "/post/1",
+			ExpectedResponseStatus: http.StatusNoContent,
+			Before: func(test *ApiTest) {
+				mocks.Add(Mock{Method: http.MethodDelete, URL: "http://example.com/posts/1", Status: http.StatusAccepted, Body: `{}`})
+			},
+		})
+
+	diagram := report.Document.Diagrams[0]
+	assert.Len(t, diagram.Events, 4)
+	assert.Equal(t, "example.com", diagram.Events[2].From())
+	assert.Equal(t, http.StatusAccepted, diagram.Events[2].(sequence.HttpResponse).Value.StatusCode)
+	assert.Equal(t, "consumer", diagram.Events[3].To())
+}
+
+func TestApiTest_RestoresDefaultTransport(t *testing.T) {
+	original := http.DefaultTransport
+
+	New(postsHandler()).WithMocks(NewMockTransport()).Run(t, TestCase{
+		Name:                   "downstream unavailable",
+		RequestMethod:          http.MethodGet,
+		RequestURL:             "/ping",
+		ExpectedResponseStatus: http.StatusNoContent,
+	})
+
+	assert.Equal(t, original, http.DefaultTransport)
+}
+
+func postsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/post", func(w http.ResponseWriter, r *http.Request) {
+		res, err := http.Get("http://example.com/posts")
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		w.Write(body)
+	})
+	mux.HandleFunc("/post/1", func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequest(http.MethodDelete, "http://example.com/posts/1", nil)
+		if _, err := http.DefaultClient.Do(req); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+func TestApiTest_WritesReportPerTestCase(t *testing.T) {
+	dir := t.TempDir()
+
+	New(postsHandler()).
+		WithReportWriter(sequence.NewReportWriter(dir)).
+		Run(t, TestCase{
+			Name:                   "ping",
+			RequestMethod:          http.MethodGet,
+			RequestURL:             "/ping",
+			ExpectedResponseStatus: http.StatusNoContent,
+		})
+
+	html, err := ioutil.ReadFile(filepath.Join(dir, "TestApiTest_WritesReportPerTestCase_ping.html"))
+	assert.Nil(t, err)
+	assert.Contains(t, string(html), "<title>GET /ping</title>")
+	_, err = ioutil.ReadFile(filepath.Join(dir, "index.html"))
+	assert.Nil(t, err)
+}
@@ -0,0 +1,90 @@
+package apitest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// Mock is a canned response to an outbound call matched by method and URL
+type Mock struct {
+	Method string
+	URL    string
+	Status int
+	Header http.Header
+	Body   string
+}
+
+// MockTransport is a Mocks implementation that answers each outbound call with the first unused Mock
+// that matches it
+type MockTransport struct {
+	mocks     []Mock
+	used      []bool
+	unmatched []*http.Request
+	mu        sync.Mutex
+}
+
+func NewMockTransport(mocks ...Mock) *MockTransport {
+	return (&MockTransport{}).Add(mocks...)
+}
+
+func (m *MockTransport) Add(mocks ...Mock) *MockTransport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mocks = append(m.mocks, mocks...)
+	m.used = append(m.used, make([]bool, len(mocks))...)
+	return m
+}
+
+func (m *MockTransport) Transport() http.RoundTripper {
+	return m
+}
+
+func (m *MockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, mock := range m.mocks {
+		if m.used[i] || mock.Method != req.Method || mock.URL != req.URL.String() {
+			continue
+		}
+		m.used[i] = true
+		header := mock.Header
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			StatusCode:    mock.Status,
+			Status:        fmt.Sprintf("%d %s", mock.Status, http.StatusText(mock.Status)),
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewBufferString(mock.Body)),
+			ContentLength: int64(len(mock.Body)),
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Request:       req,
+		}, nil
+	}
+	m.unmatched = append(m.unmatched, req)
+	return nil, fmt.Errorf("no mock matches %s %s", req.Method, req.URL)
+}
+
+func (m *MockTransport) Verify() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.unmatched) > 0 {
+		return fmt.Errorf("unmatched outbound request: %s %s", m.unmatched[0].Method, m.unmatched[0].URL)
+	}
+	for i, used := range m.used {
+		if !used {
+			return fmt.Errorf("expected outbound request was not made: %s %s", m.mocks[i].Method, m.mocks[i].URL)
+		}
+	}
+	return nil
+}
+
+func (m *MockTransport) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mocks, m.used, m.unmatched = nil, nil, nil
+}
@@ -0,0 +1,42 @@
+package apitest
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestMockTransport_AnswersMatchingRequest(t *testing.T) {
+	mocks := NewMockTransport(Mock{Method: http.MethodGet, URL: "http://example.com/posts", Status: http.StatusOK, Body: "posts"})
+
+	res, err := (&http.Client{Transport: mocks.Transport()}).Get("http://example.com/posts")
+
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(res.Body)
+	assert.Equal(t, "posts", string(body))
+	assert.Nil(t, mocks.Verify())
+}
+
+func TestMockTransport_Verify_ErrorIfRequestUnmatched(t *testing.T) {
+	mocks := NewMockTransport()
+
+	_, err := (&http.Client{Transport: mocks}).Get("http://example.com/posts")
+
+	assert.Error(t, err)
+	assert.EqualError(t, mocks.Verify(), "unmatched outbound request: GET http://example.com/posts")
+}
+
+func TestMockTransport_Verify_ErrorIfMockNotUsed(t *testing.T) {
+	mocks := NewMockTransport(Mock{Method: http.MethodDelete, URL: "http://example.com/posts/1", Status: http.StatusNoContent})
+
+	assert.EqualError(t, mocks.Verify(), "expected outbound request was not made: DELETE http://example.com/posts/1")
+}
+
+func TestMockTransport_Reset(t *testing.T) {
+	mocks := NewMockTransport(Mock{Method: http.MethodGet, URL: "http://example.com/posts", Status: http.StatusOK})
+
+	mocks.Reset()
+
+	assert.Nil(t, mocks.Verify())
+}
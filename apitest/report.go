@@ -0,0 +1,40 @@
+package apitest
+
+import (
+	"github.com/steinfletcher/sequence-diagrams"
+	"io/ioutil"
+	"sync"
+)
+
+// Report collects the diagrams of every test case in a package into a single Document. Create one per
+// test package and write it from TestMain once the tests have run
+type Report struct {
+	Document *sequence.Document
+	mu       sync.Mutex
+}
+
+func NewReport(title string) *Report {
+	return &Report{Document: sequence.NewDocument().AddTitle(title)}
+}
+
+func (r *Report) AddDiagram(diagram *sequence.Diagram) *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Document.AddDiagram(diagram)
+	return r
+}
+
+// WriteFile renders the report as HTML to the given path. Nothing is written if no test case was recorded
+func (r *Report) WriteFile(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.Document.Diagrams) == 0 {
+		return nil
+	}
+
+	html, err := r.Document.RenderHTML()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(html), 0644)
+}
@@ -0,0 +1,42 @@
+package apitest
+
+import (
+	"github.com/steinfletcher/sequence-diagrams"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReport_WriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.html")
+	report := NewReport("Posts API").AddDiagram(aDiagram("first")).AddDiagram(aDiagram("second"))
+
+	err := report.WriteFile(path)
+
+	assert.Nil(t, err)
+	html, _ := ioutil.ReadFile(path)
+	assert.Contains(t, string(html), "<title>Posts API</title>")
+	assert.Contains(t, string(html), "<h1>first</h1>")
+	assert.Contains(t, string(html), "<h1>second</h1>")
+}
+
+func TestReport_WriteFile_SkipsEmptyReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.html")
+
+	err := NewReport("Posts API").WriteFile(path)
+
+	assert.Nil(t, err)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func aDiagram(title string) *sequence.Diagram {
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/posts", nil)
+	return sequence.NewDiagram().
+		AddTitle(title).
+		AddHttpRequest(sequence.HttpRequest{Source: "consumer", Target: "app", Value: req}).
+		AddHttpResponse(sequence.HttpResponse{Source: "app", Target: "consumer", Value: &http.Response{StatusCode: http.StatusOK}})
+}
@@ -0,0 +1,100 @@
+package sequence
+
+//go:generate go run assets/generate.go
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+//go:embed assets/vendor
+var embeddedAssets embed.FS
+
+// assetDir is the directory holding the asset manifest and the downloaded assets
+const assetDir = "assets/vendor"
+
+// assetFS holds the assets inlined into documents that use embedded assets
+var assetFS fs.FS = embeddedAssets
+
+type (
+	// HtmlAsset is a stylesheet or script included in the HTML report, either linked from a CDN or inlined
+	HtmlAsset struct {
+		URL    string
+		Inline string
+	}
+
+	asset struct {
+		name string
+		url  string
+	}
+)
+
+func (r HtmlAsset) InlineCSS() template.CSS {
+	return template.CSS(r.Inline)
+}
+
+// InlineJS returns the script with closing script tags escaped so it cannot end the surrounding element
+func (r HtmlAsset) InlineJS() template.JS {
+	return template.JS(strings.Replace(r.Inline, "</script", `<\/script`, -1))
+}
+
+// UseEmbeddedAssets inlines every stylesheet and script into the rendered HTML so the report works
+// without network access. By default assets are linked from their CDNs
+func (r *Document) UseEmbeddedAssets() *Document {
+	r.EmbeddedAssets = true
+	return r
+}
+
+// buildAssetModels returns the stylesheets and scripts to include in the report
+func buildAssetModels(embedded bool) (stylesheets []HtmlAsset, scripts []HtmlAsset, err error) {
+	assets, err := readManifest()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, a := range assets {
+		model := HtmlAsset{URL: a.url}
+		if embedded {
+			content, err := fs.ReadFile(assetFS, path.Join(assetDir, a.name))
+			if err != nil {
+				return nil, nil, fmt.Errorf("asset %s is not embedded, run go generate to download it: %v", a.name, err)
+			}
+			model.Inline = string(content)
+		}
+
+		if strings.HasSuffix(a.name, ".css") {
+			stylesheets = append(stylesheets, model)
+		} else {
+			scripts = append(scripts, model)
+		}
+	}
+	return stylesheets, scripts, nil
+}
+
+func readManifest() ([]asset, error) {
+	manifest, err := fs.ReadFile(embeddedAssets, path.Join(assetDir, "manifest.txt"))
+	if err != nil {
+		return nil, err
+	}
+
+	var assets []asset
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid asset manifest line %q", line)
+		}
+		assets = append(assets, asset{name: fields[0], url: fields[1]})
+	}
+	return assets, scanner.Err()
+}
@@ -0,0 +1,69 @@
+//go:build ignore
+// +build ignore
+
+// generate downloads every asset listed in vendor/manifest.txt into the vendor directory so it can be
+// embedded in the package
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const vendorDir = "assets/vendor"
+
+func main() {
+	manifest, err := os.Open(filepath.Join(vendorDir, "manifest.txt"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer manifest.Close()
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	scanner := bufio.NewScanner(manifest)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			log.Fatalf("invalid manifest line %q", line)
+		}
+		if err := download(client, fields[1], filepath.Join(vendorDir, fields[0])); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func download(client *http.Client, url, path string) error {
+	res, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, res.Status)
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, res.Body); err != nil {
+		out.Close()
+		return err
+	}
+	log.Printf("downloaded %s", path)
+	return out.Close()
+}
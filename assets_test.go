@@ -0,0 +1,72 @@
+package sequence
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/fs"
+	"path"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDocument_RenderHTML_LinksAssetsFromCDNByDefault(t *testing.T) {
+	html, err := NewDocument().AddDiagram(aDiagram()).RenderHTML()
+
+	assert.Nil(t, err)
+	assert.Contains(t, html, `<link rel="stylesheet" href="https://stackpath.bootstrapcdn.com/bootstrap/4.1.2/css/bootstrap.min.css">`)
+	assert.Contains(t, html, `<script src="https://bramp.github.io/js-sequence-diagrams/js/sequence-diagram-min.js"></script>`)
+}
+
+func TestDocument_RenderHTML_InlinesEmbeddedAssets(t *testing.T) {
+	withAssets(t, fakeAssets())
+
+	html, err := NewDocument().AddDiagram(aDiagram()).UseEmbeddedAssets().RenderHTML()
+
+	assert.Nil(t, err)
+	assert.Contains(t, html, "<style>.bootstrap-css{}</style>")
+	assert.Contains(t, html, `<script>var raphael = "<\/script>";</script>`)
+	assert.NotContains(t, html, "https://")
+}
+
+func TestDocument_RenderHTML_ErrorIfEmbeddedAssetMissing(t *testing.T) {
+	assets := fakeAssets()
+	delete(assets, path.Join(assetDir, "raphael.min.js"))
+	withAssets(t, assets)
+
+	_, err := NewDocument().AddDiagram(aDiagram()).UseEmbeddedAssets().RenderHTML()
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "asset raphael.min.js is not embedded, run go generate to download it")
+}
+
+func TestDocument_RenderHTML_InlinesCommittedAssets(t *testing.T) {
+	manifest, err := readManifest()
+	assert.Nil(t, err)
+	for _, a := range manifest {
+		_, err := fs.Stat(embeddedAssets, path.Join(assetDir, a.name))
+		assert.Nil(t, err, "asset %s is not committed, run go generate and commit the %s directory", a.name, assetDir)
+	}
+
+	html, err := NewDocument().AddDiagram(aDiagram()).UseEmbeddedAssets().RenderHTML()
+
+	assert.Nil(t, err)
+	assert.NotContains(t, html, "<script src=")
+	assert.NotContains(t, html, "<link rel=\"stylesheet\"")
+	assert.Contains(t, html, "Bootstrap v4.1.2")
+}
+
+func withAssets(t *testing.T, assets fstest.MapFS) {
+	original := assetFS
+	assetFS = assets
+	t.Cleanup(func() { assetFS = original })
+}
+
+func fakeAssets() fstest.MapFS {
+	assets := fstest.MapFS{}
+	manifest, _ := readManifest()
+	for _, a := range manifest {
+		assets[path.Join(assetDir, a.name)] = &fstest.MapFile{Data: []byte("/* " + a.name + " */")}
+	}
+	assets[path.Join(assetDir, "bootstrap.min.css")] = &fstest.MapFile{Data: []byte(".bootstrap-css{}")}
+	assets[path.Join(assetDir, "raphael.min.js")] = &fstest.MapFile{Data: []byte(`var raphael = "</script>";`)}
+	return assets
+}
@@ -0,0 +1,214 @@
+// Command seqdiag renders sequence diagrams described in a JSON or YAML file.
+//
+//	seqdiag --format mermaid diagram.yaml
+//
+// The input describes a document with one or more diagrams:
+//
+//	title: Posts API
+//	description: Creating a post
+//	diagrams:
+//	  - title: POST /post
+//	    subTitle: creates a post
+//	    events:
+//	      - type: request
+//	        source: consumer
+//	        target: app
+//	        header: POST /post
+//	        body: '{"title": "go rulez"}'
+//	      - type: response
+//	        source: app
+//	        target: consumer
+//	        header: 201 Created
+//
+// Hand written descriptions hold request and response events only. Notes, dividers, groups and participant
+// declarations are not supported in them, but are rendered from recordings.
+//
+// Files are read as YAML when their extension is .yaml or .yml and stdin is read as YAML unless it holds a
+// JSON object; --input json or --input yaml overrides the detection. Unknown fields are an error in both.
+//
+// JSON recordings written by sequence.Document's MarshalJSON, which carry a "version" field, are rendered as recorded.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/steinfletcher/sequence-diagrams"
+	"gopkg.in/yaml.v2"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type (
+	documentSpec struct {
+		Title       string        `json:"title" yaml:"title"`
+		Description string        `json:"description" yaml:"description"`
+		Diagrams    []diagramSpec `json:"diagrams" yaml:"diagrams"`
+	}
+
+	diagramSpec struct {
+		Title    string      `json:"title" yaml:"title"`
+		SubTitle string      `json:"subTitle" yaml:"subTitle"`
+		Events   []eventSpec `json:"events" yaml:"events"`
+	}
+
+	eventSpec struct {
+		Type   string `json:"type" yaml:"type"`
+		Source string `json:"source" yaml:"source"`
+		Target string `json:"target" yaml:"target"`
+		Header string `json:"header" yaml:"header"`
+		Body   string `json:"body" yaml:"body"`
+	}
+)
+
+var formats = []string{"html", "markdown", "mermaid", "plantuml", "wsd"}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "seqdiag:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("seqdiag", flag.ContinueOnError)
+	format := flags.String("format", "html", "output format, one of "+strings.Join(formats, ", "))
+	output := flags.String("o", "", "file to write to, defaults to stdout")
+	inputFormat := flags.String("input", "auto", "input format, one of auto, json, yaml. auto uses the file extension, or the content when reading stdin")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: seqdiag [--format html|markdown|mermaid|plantuml|wsd] [--input auto|json|yaml] [-o file] [input.json|input.yaml|-]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return errors.New("expected a single input file")
+	}
+
+	input, err := readInput(flags.Arg(0), stdin)
+	if err != nil {
+		return err
+	}
+	isYAML, err := detectYAML(*inputFormat, flags.Arg(0), input)
+	if err != nil {
+		return err
+	}
+	document, err := parseDocument(input, isYAML)
+	if err != nil {
+		return err
+	}
+	rendered, err := render(document, *format)
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = io.WriteString(stdout, rendered)
+		return err
+	}
+	return ioutil.WriteFile(*output, []byte(rendered), 0644)
+}
+
+// readInput reads the named file, or stdin when the name is empty or "-"
+func readInput(name string, stdin io.Reader) ([]byte, error) {
+	if name == "" || name == "-" {
+		return ioutil.ReadAll(stdin)
+	}
+	return ioutil.ReadFile(name)
+}
+
+// detectYAML reports whether the input is YAML. In auto mode a file is YAML when its extension is .yaml
+// or .yml, and stdin is YAML unless it starts with a JSON object
+func detectYAML(format, name string, input []byte) (bool, error) {
+	switch format {
+	case "json":
+		return false, nil
+	case "yaml":
+		return true, nil
+	case "auto":
+	default:
+		return false, fmt.Errorf("unknown input format %q, expected auto, json or yaml", format)
+	}
+	if name != "" && name != "-" {
+		ext := strings.ToLower(filepath.Ext(name))
+		return ext == ".yaml" || ext == ".yml", nil
+	}
+	return !bytes.HasPrefix(bytes.TrimSpace(input), []byte("{")), nil
+}
+
+func parseDocument(input []byte, isYAML bool) (*sequence.Document, error) {
+	if !isYAML && isRecording(input) {
+		var document sequence.Document
+		if err := json.Unmarshal(input, &document); err != nil {
+			return nil, fmt.Errorf("invalid recording: %v", err)
+		}
+		return &document, nil
+	}
+
+	var spec documentSpec
+	var err error
+	if isYAML {
+		err = yaml.UnmarshalStrict(input, &spec)
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader(input))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&spec)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid document: %v", err)
+	}
+
+	document := sequence.NewDocument().
+		AddTitle(spec.Title).
+		AddDescription(spec.Description)
+	for i, d := range spec.Diagrams {
+		diagram := sequence.NewDiagram().
+			AddTitle(d.Title).
+			AddSubTitle(d.SubTitle)
+		for j, e := range d.Events {
+			switch e.Type {
+			case "request":
+				diagram.AddMessageRequest(sequence.MessageRequest{Source: e.Source, Target: e.Target, Header: e.Header, Body: e.Body})
+			case "response":
+				diagram.AddMessageResponse(sequence.MessageResponse{Source: e.Source, Target: e.Target, Header: e.Header, Body: e.Body})
+			default:
+				return nil, fmt.Errorf("diagram %d event %d: unknown type %q, expected request or response", i+1, j+1, e.Type)
+			}
+		}
+		document.AddDiagram(diagram)
+	}
+	return document, nil
+}
+
+// isRecording reports whether the input is a versioned JSON recording rather than a hand written description
+func isRecording(input []byte) bool {
+	var header struct {
+		Version *int `json:"version"`
+	}
+	return json.Unmarshal(input, &header) == nil && header.Version != nil
+}
+
+// render writes the document in the given format. Formats without a notion of a document write each
+// diagram in turn separated by a blank line
+func render(document *sequence.Document, format string) (string, error) {
+	switch format {
+	case "html":
+		return document.RenderHTML()
+	case "markdown":
+		return document.RenderMarkdown()
+	case "plantuml":
+		return document.RenderPlantUML()
+	case "mermaid":
+		return document.RenderMermaid()
+	case "wsd":
+		return document.RenderWebSequenceDSL()
+	default:
+		return "", fmt.Errorf("unknown format %q, expected one of %s", format, strings.Join(formats, ", "))
+	}
+}
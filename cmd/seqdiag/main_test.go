@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const jsonInput = `{
+  "title": "Posts API",
+  "diagrams": [{
+    "title": "POST /post",
+    "events": [
+      {"type": "request", "source": "consumer", "target": "app", "header": "POST /post", "body": "{}"},
+      {"type": "response", "source": "app", "target": "consumer", "header": "201 Created"}
+    ]
+  }]
+}`
+
+const yamlInput = `
+title: Posts API
+diagrams:
+  - title: POST /post
+    events:
+      - type: request
+        source: consumer
+        target: app
+        header: POST /post
+      - type: response
+        source: app
+        target: consumer
+        header: 201 Created
+`
+
+func TestRun_RendersFormats(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{format: "wsd", expected: "consumer->app: (1) POST /post\napp->>consumer: (2) 201 Created\n"},
+		{format: "mermaid", expected: "sequenceDiagram\n    participant consumer\n    participant app\n    consumer->>app: (1) POST /post\n    app-->>consumer: (2) 201 Created\n"},
+		{format: "plantuml", expected: "@startuml\ntitle POST /post\nparticipant consumer\nparticipant app\nconsumer -> app : (1) POST /post\napp --> consumer : (2) 201 Created\n@enduml\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			var out bytes.Buffer
+
+			err := run([]string{"--format", test.format}, strings.NewReader(jsonInput), &out)
+
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, out.String())
+		})
+	}
+}
+
+func TestRun_RendersHTMLByDefault(t *testing.T) {
+	var out bytes.Buffer
+
+	err := run(nil, strings.NewReader(jsonInput), &out)
+
+	assert.Nil(t, err)
+	assert.Contains(t, out.String(), "<title>Posts API</title>")
+}
+
+func TestRun_ReadsYAMLFileAndWritesOutputFile(t *testing.T) {
+	dir := t.TempDir()
+	input, output := filepath.Join(dir, "diagram.yml"), filepath.Join(dir, "diagram.md")
+	ioutil.WriteFile(input, []byte(yamlInput), 0644)
+
+	err := run([]string{"--format", "markdown", "-o", output, input}, nil, nil)
+
+	assert.Nil(t, err)
+	markdown, _ := ioutil.ReadFile(output)
+	assert.Contains(t, string(markdown), "# Posts API\n")
+	assert.Contains(t, string(markdown), "```mermaid\n")
+}
+
+func TestRun_ReadsYAMLFromStdin(t *testing.T) {
+	var out bytes.Buffer
+
+	err := run([]string{"--format", "wsd"}, strings.NewReader(yamlInput), &out)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "consumer->app: (1) POST /post\napp->>consumer: (2) 201 Created\n", out.String())
+}
+
+func TestRun_InputFormatOverridesDetection(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "diagram.txt")
+	ioutil.WriteFile(input, []byte(yamlInput), 0644)
+
+	err := run([]string{"--format", "wsd", "--input", "yaml", input}, nil, &bytes.Buffer{})
+	assert.Nil(t, err)
+
+	err = run([]string{"--format", "wsd", "--input", "toml", input}, nil, &bytes.Buffer{})
+	assert.EqualError(t, err, `unknown input format "toml", expected auto, json or yaml`)
+}
+
+func TestRun_ErrorIfUnknownField(t *testing.T) {
+	err := run([]string{"--format", "wsd"}, strings.NewReader(`{"title": "Posts", "diagram": []}`), &bytes.Buffer{})
+	assert.EqualError(t, err, `invalid document: json: unknown field "diagram"`)
+
+	err = run([]string{"--format", "wsd"}, strings.NewReader("title: Posts\ndiagram: []\n"), &bytes.Buffer{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "field diagram not found")
+}
+
+func TestRun_ErrorIfUnknownFormat(t *testing.T) {
+	err := run([]string{"--format", "png"}, strings.NewReader(jsonInput), &bytes.Buffer{})
+
+	assert.EqualError(t, err, `unknown format "png", expected one of html, markdown, mermaid, plantuml, wsd`)
+}
+
+func TestRun_ErrorIfUnknownEventType(t *testing.T) {
+	input := `{"diagrams": [{"events": [{"type": "note"}]}]}`
+
+	err := run([]string{"--format", "wsd"}, strings.NewReader(input), &bytes.Buffer{})
+
+	assert.EqualError(t, err, `diagram 1 event 1: unknown type "note", expected request or response`)
+}
+
+func TestRun_RendersRecording(t *testing.T) {
+	input := `{"version": 1, "title": "Posts API", "diagrams": [{"events": [
+		{"type": "http_request", "source": "consumer", "target": "app", "method": "GET", "url": "http://app/posts"},
+		{"type": "http_response", "source": "app", "target": "consumer", "status": 200}
+	]}]}`
+	var out bytes.Buffer
+
+	err := run([]string{"--format", "wsd"}, strings.NewReader(input), &out)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "consumer->app: (1) GET http://app/posts\napp->>consumer: (2) 200\n", out.String())
+}
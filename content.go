@@ -0,0 +1,220 @@
+package sequence
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/andybalholm/brotli"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// hexdumpLimit is the number of leading bytes of a binary body shown as a hexdump
+const hexdumpLimit = 256
+
+// ContentDecoder decodes a body sent with a Content-Encoding, such as gzip
+type ContentDecoder func(r io.Reader) (io.Reader, error)
+
+var contentDecoders = struct {
+	sync.RWMutex
+	byEncoding map[string]ContentDecoder
+}{byEncoding: map[string]ContentDecoder{
+	"gzip":    gunzip,
+	"x-gzip":  gunzip,
+	"deflate": inflate,
+	"br":      unbrotli,
+}}
+
+// RegisterContentDecoder sets the decoder used for bodies with the given Content-Encoding. gzip, deflate
+// and br are decoded out of the box
+func RegisterContentDecoder(encoding string, decoder ContentDecoder) {
+	contentDecoders.Lock()
+	defer contentDecoders.Unlock()
+	contentDecoders.byEncoding[strings.ToLower(encoding)] = decoder
+}
+
+func gunzip(r io.Reader) (io.Reader, error) {
+	return gzip.NewReader(r)
+}
+
+func unbrotli(r io.Reader) (io.Reader, error) {
+	return brotli.NewReader(r), nil
+}
+
+// inflate decodes the zlib format that deflate names in HTTP, falling back to raw deflate which some
+// servers send instead
+func inflate(r io.Reader) (io.Reader, error) {
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if zr, err := zlib.NewReader(bytes.NewReader(body)); err == nil {
+		return zr, nil
+	}
+	return flate.NewReader(bytes.NewReader(body)), nil
+}
+
+// formatHttpBody decodes the body according to its Content-Encoding and formats it according to its
+// Content-Type. Bodies that cannot be decoded are summarised. When max is positive at most max bytes of
+// the decoded body are kept, and a longer body is cut instead of formatted. It reports whether it was cut
+func formatHttpBody(body []byte, header http.Header, max int) (string, string, bool, error) {
+	encoding := header.Get("Content-Encoding")
+	decoded, size, err := readContent(body, encoding, max)
+	if err != nil {
+		return fmt.Sprintf("%s encoded body could not be decoded: %v\n%s", encoding, err, binarySummary(body)), "", false, nil
+	}
+	if len(decoded) < size {
+		formatted, language := truncatedContent(decoded, size, header.Get("Content-Type"))
+		return formatted, language, true, nil
+	}
+	formatted, language, err := formatBody(decoded, header.Get("Content-Type"))
+	return formatted, language, false, err
+}
+
+// decodeContent undoes each content coding in the reverse of the order they were applied
+func decodeContent(body []byte, encoding string) ([]byte, error) {
+	decoded, _, err := readContent(body, encoding, 0)
+	return decoded, err
+}
+
+// readContent decodes the body, keeping at most max bytes of the decoded content when max is positive.
+// The rest is counted without being kept, and the size of the whole decoded content is returned
+func readContent(body []byte, encoding string, max int) ([]byte, int, error) {
+	reader, err := contentReader(body, encoding)
+	if err != nil {
+		return nil, 0, err
+	}
+	if max <= 0 {
+		decoded, err := ioutil.ReadAll(reader)
+		return decoded, len(decoded), err
+	}
+	var kept bytes.Buffer
+	if _, err := io.CopyN(&kept, reader, int64(max)); err != nil && err != io.EOF {
+		return nil, 0, err
+	}
+	rest, err := io.Copy(ioutil.Discard, reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	return kept.Bytes(), kept.Len() + int(rest), nil
+}
+
+// contentReader returns a reader that undoes each content coding of the body in the reverse of the
+// order they were applied
+func contentReader(body []byte, encoding string) (io.Reader, error) {
+	var reader io.Reader = bytes.NewReader(body)
+	if len(body) == 0 || encoding == "" {
+		return reader, nil
+	}
+	codings := strings.Split(encoding, ",")
+	for i := len(codings) - 1; i >= 0; i-- {
+		coding := strings.ToLower(strings.TrimSpace(codings[i]))
+		if coding == "" || coding == "identity" {
+			continue
+		}
+		contentDecoders.RLock()
+		decoder, ok := contentDecoders.byEncoding[coding]
+		contentDecoders.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("no decoder for content encoding %q, see RegisterContentDecoder", coding)
+		}
+
+		var err error
+		if reader, err = decoder(reader); err != nil {
+			return nil, err
+		}
+	}
+	return reader, nil
+}
+
+// isBinary reports whether the body is not text, that is it is not valid UTF-8 or holds control
+// characters other than whitespace and escape
+func isBinary(body []byte) bool {
+	if !utf8.Valid(body) {
+		return true
+	}
+	for _, b := range body {
+		if (b < 0x20 && !strings.ContainsRune("\t\n\v\f\r\x1b", rune(b))) || b == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// binarySummary describes a binary body by its size and digest, followed by a hexdump of its first bytes
+func binarySummary(body []byte) string {
+	summary := fmt.Sprintf("binary, %d bytes, sha256 %x\n\n", len(body), sha256.Sum256(body))
+	if len(body) <= hexdumpLimit {
+		return summary + hex.Dump(body)
+	}
+	return summary + hex.Dump(body[:hexdumpLimit]) + fmt.Sprintf("… %d more bytes", len(body)-hexdumpLimit)
+}
+
+// formatMultipart shows each part of a multipart body with its headers and its formatted body
+func formatMultipart(body []byte, boundary string) (string, error) {
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+	var out bytes.Buffer
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		content, err := ioutil.ReadAll(part)
+		if err != nil {
+			return "", err
+		}
+
+		out.WriteString("--" + boundary + "\n")
+		writeMIMEHeader(&out, part.Header)
+		out.WriteString("\n")
+		formatted, _, err := formatBody(content, part.Header.Get("Content-Type"))
+		if err != nil {
+			return "", err
+		}
+		if formatted != "" {
+			out.WriteString(strings.TrimSuffix(formatted, "\n") + "\n")
+		}
+	}
+	if out.Len() == 0 {
+		return "", fmt.Errorf("multipart body has no parts")
+	}
+	out.WriteString("--" + boundary + "--")
+	return out.String(), nil
+}
+
+func writeMIMEHeader(out *bytes.Buffer, header textproto.MIMEHeader) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			out.WriteString(name + ": " + value + "\n")
+		}
+	}
+}
+
+// multipartBoundary returns the boundary of a multipart content type
+func multipartBoundary(contentType string) (string, bool) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return "", false
+	}
+	return params["boundary"], true
+}
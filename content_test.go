@@ -0,0 +1,128 @@
+package sequence
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+func TestFormatHttpBody_DecodesContentEncoding(t *testing.T) {
+	var gzipped, zlibbed, deflated, brotlied bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	gz.Write([]byte(`{"a":1}`))
+	gz.Close()
+	zw := zlib.NewWriter(&zlibbed)
+	zw.Write([]byte(`{"a":1}`))
+	zw.Close()
+	fw, _ := flate.NewWriter(&deflated, flate.DefaultCompression)
+	fw.Write([]byte(`{"a":1}`))
+	fw.Close()
+	bw := brotli.NewWriter(&brotlied)
+	bw.Write([]byte(`{"a":1}`))
+	bw.Close()
+
+	tests := []struct {
+		encoding string
+		body     []byte
+	}{
+		{encoding: "gzip", body: gzipped.Bytes()},
+		{encoding: "deflate", body: zlibbed.Bytes()},
+		{encoding: "deflate", body: deflated.Bytes()},
+		{encoding: "br", body: brotlied.Bytes()},
+		{encoding: "identity, gzip", body: gzipped.Bytes()},
+	}
+	for _, test := range tests {
+		t.Run(test.encoding, func(t *testing.T) {
+			header := http.Header{"Content-Type": []string{"application/json"}, "Content-Encoding": []string{test.encoding}}
+
+			formatted, language, _, err := formatHttpBody(test.body, header, 0)
+
+			assert.Nil(t, err)
+			assert.Equal(t, "{\n    \"a\": 1\n}", formatted)
+			assert.Equal(t, "json", language)
+		})
+	}
+}
+
+func TestFormatHttpBody_SummarisesUnknownContentEncoding(t *testing.T) {
+	header := http.Header{"Content-Encoding": []string{"zstd"}}
+
+	formatted, _, _, err := formatHttpBody([]byte{0x1b, 0x03}, header, 0)
+
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(formatted, "zstd encoded body could not be decoded: no decoder for content encoding \"zstd\", see RegisterContentDecoder\nbinary, 2 bytes, sha256 "))
+}
+
+func TestRegisterContentDecoder(t *testing.T) {
+	RegisterContentDecoder("x-reverse", func(r io.Reader) (io.Reader, error) {
+		body, err := ioutil.ReadAll(r)
+		for i, j := 0, len(body)-1; i < j; i, j = i+1, j-1 {
+			body[i], body[j] = body[j], body[i]
+		}
+		return bytes.NewReader(body), err
+	})
+
+	formatted, _, _, err := formatHttpBody([]byte("olleh"), http.Header{"Content-Encoding": []string{"X-Reverse"}}, 0)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", formatted)
+}
+
+func TestFormatBody_SummarisesBinaryContent(t *testing.T) {
+	body := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 300)...)
+
+	formatted, language, err := formatBody(body, "image/png")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "", language)
+	assert.True(t, strings.HasPrefix(formatted, "binary, 308 bytes, sha256 "))
+	assert.Contains(t, formatted, "00000000  89 50 4e 47 0d 0a 1a 0a  00 00 00 00 00 00 00 00  |.PNG............|\n")
+	assert.True(t, strings.HasSuffix(formatted, "… 52 more bytes"))
+}
+
+func TestFormatBody_ShowsMultipartParts(t *testing.T) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.SetBoundary("b0undary")
+	writer.WriteField("title", "go")
+	part, _ := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Disposition": []string{`form-data; name="file"; filename="a.bin"`},
+		"Content-Type":        []string{"application/octet-stream"},
+	})
+	part.Write([]byte{0x00, 0x01})
+	json, _ := writer.CreatePart(textproto.MIMEHeader{"Content-Type": []string{"application/json"}})
+	json.Write([]byte(`{"a":1}`))
+	writer.Close()
+
+	formatted, language, err := formatBody(body.Bytes(), writer.FormDataContentType())
+
+	assert.Nil(t, err)
+	assert.Equal(t, "", language)
+	assert.True(t, strings.HasPrefix(formatted, "--b0undary\n"+
+		"Content-Disposition: form-data; name=\"title\"\n\n"+
+		"go\n"+
+		"--b0undary\n"+
+		"Content-Disposition: form-data; name=\"file\"; filename=\"a.bin\"\n"+
+		"Content-Type: application/octet-stream\n\n"+
+		"binary, 2 bytes, sha256 "))
+	assert.True(t, strings.HasSuffix(formatted, "--b0undary\n"+
+		"Content-Type: application/json\n\n"+
+		"{\n    \"a\": 1\n}\n"+
+		"--b0undary--"))
+}
+
+func TestIsBinary(t *testing.T) {
+	assert.False(t, isBinary([]byte("text\twith\r\nwhitespace and ünïcode")))
+	assert.True(t, isBinary([]byte{0x08, 0x96, 0x01}))
+	assert.True(t, isBinary([]byte("nul\x00")))
+}
@@ -2,7 +2,6 @@ package sequence
 
 import (
 	"bytes"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"html/template"
@@ -11,20 +10,61 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 type (
 	Document struct {
-		Diagrams    []*Diagram
-		Title       string
-		Description string
-		MetaJSON    template.JS
+		Diagrams       []*Diagram
+		Title          string
+		Description    string
+		MetaJSON       template.JS
+		EmbeddedAssets bool
+		StaticSVG      bool
+		Redaction      *Redaction
+		Resolver       *ParticipantResolver
+		MaxBodySize    int
+		Sidecar        *BodySidecar
 	}
 
 	Diagram struct {
-		Title    string
-		SubTitle string
-		Events   []interface{}
+		Title        string
+		SubTitle     string
+		Participants []Participant
+		Events       []Event
+		Redaction    *Redaction
+		Resolver     *ParticipantResolver
+		MaxBodySize  int
+		mu           sync.Mutex
+	}
+
+	// Event is a single message drawn as an arrow between two participants.
+	// Implement it to add event types beyond the built in HTTP and message events.
+	Event interface {
+		// From returns the participant the arrow starts at
+		From() string
+		// To returns the participant the arrow points to
+		To() string
+		// Label returns the text drawn on the arrow
+		Label() string
+		// LogEntry returns the wire representation shown in the log table
+		LogEntry() (LogEntry, error)
+		// IsResponse reports whether the event is a reply to an earlier request
+		IsResponse() bool
+	}
+
+	// StatusCoder is implemented by response events that carry a status code. The status of the
+	// final event is shown as the diagram's badge
+	StatusCoder interface {
+		StatusCode() int
+	}
+
+	// Timer is implemented by events that record when they happened. Either time is zero when it is not
+	// known. Events that know both are drawn with their duration
+	Timer interface {
+		Timing() (start, end time.Time)
 	}
 
 	DocumentHtmlModel struct {
@@ -32,27 +72,45 @@ type (
 		Description string
 		Diagrams    []DiagramHtmlModel
 		MetaJSON    template.JS
+		Stylesheets []HtmlAsset
+		Scripts     []HtmlAsset
 	}
 
 	DiagramHtmlModel struct {
 		WebSequenceDSL string
+		SVG            template.HTML
 		Title          string
 		SubTitle       string
 		BadgeClass     string
 		StatusCode     int
+		TotalTime      string
 		LogEntries     []LogEntry
 	}
 
 	LogEntry struct {
 		Header string
 		Body   string
+		// Language is the highlight.js language of the body, if any
+		Language string
+		// BodyURL links to the full body when Body was truncated and stored in a sidecar file
+		BodyURL string
+		// Latency is how long the event took, if it records its start and end
+		Latency string
+		// Annotation is set for notes, dividers and groups, which are shown as unnumbered rows of the log
+		// table rather than as payloads. It describes the annotation, such as "Note over app, db"
+		Annotation string
 	}
 
+	// MessageRequest and the other built in events optionally record Start, when the request was sent,
+	// and End, when the response was received. A response that records both is drawn with the duration
+	// of the call
 	MessageRequest struct {
 		Source string
 		Target string
 		Header string
 		Body   string
+		Start  time.Time
+		End    time.Time
 	}
 
 	MessageResponse struct {
@@ -60,18 +118,24 @@ type (
 		Target string
 		Header string
 		Body   string
+		Start  time.Time
+		End    time.Time
 	}
 
 	HttpRequest struct {
 		Source string
 		Target string
 		Value  *http.Request
+		Start  time.Time
+		End    time.Time
 	}
 
 	HttpResponse struct {
 		Source string
 		Target string
 		Value  *http.Response
+		Start  time.Time
+		End    time.Time
 	}
 )
 
@@ -103,24 +167,90 @@ func NewDiagram() *Diagram {
 	return &Diagram{}
 }
 
-func (r *Diagram) AddHttpRequest(req HttpRequest) *Diagram {
-	r.Events = append(r.Events, req)
+// AddEvent appends an event to the diagram. It is safe to call from multiple goroutines, and while the
+// diagram is rendered, so recorders can add events while requests are served concurrently. Other fields
+// must not be changed while the diagram is in use
+func (r *Diagram) AddEvent(e Event) *Diagram {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Events = append(r.Events, e)
 	return r
 }
 
+// recorded returns copies of the participants and events added so far, so the diagram can be rendered
+// while recorders are still adding to it
+func (r *Diagram) recorded() ([]Participant, []Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Participant(nil), r.Participants...), append([]Event(nil), r.Events...)
+}
+
+func (r *Diagram) AddHttpRequest(req HttpRequest) *Diagram {
+	return r.AddEvent(req)
+}
+
 func (r *Diagram) AddHttpResponse(req HttpResponse) *Diagram {
-	r.Events = append(r.Events, req)
-	return r
+	return r.AddEvent(req)
 }
 
 func (r *Diagram) AddMessageRequest(m MessageRequest) *Diagram {
-	r.Events = append(r.Events, m)
-	return r
+	return r.AddEvent(m)
 }
 
 func (r *Diagram) AddMessageResponse(m MessageResponse) *Diagram {
-	r.Events = append(r.Events, m)
-	return r
+	return r.AddEvent(m)
+}
+
+// diagrams returns the diagrams of the document, with the document's redaction, participant resolver and
+// body size limit applied to those that do not set their own
+func (r *Document) diagrams() []*Diagram {
+	if r.Redaction == nil && r.Resolver == nil && r.MaxBodySize == 0 {
+		return r.Diagrams
+	}
+	diagrams := make([]*Diagram, len(r.Diagrams))
+	for i, d := range r.Diagrams {
+		diagrams[i] = d
+		if d == nil {
+			continue
+		}
+		inheritRedaction := d.Redaction == nil && r.Redaction != nil
+		inheritResolver := d.Resolver == nil && r.Resolver != nil
+		inheritMaxBodySize := d.MaxBodySize == 0 && r.MaxBodySize != 0
+		if inheritRedaction || inheritResolver || inheritMaxBodySize {
+			diagrams[i] = d.clone()
+		}
+		if inheritRedaction {
+			diagrams[i].Redaction = r.Redaction
+		}
+		if inheritResolver {
+			diagrams[i].Resolver = r.Resolver
+		}
+		if inheritMaxBodySize {
+			diagrams[i].MaxBodySize = r.MaxBodySize
+		}
+	}
+	return diagrams
+}
+
+// renderEach renders every diagram of the document, with the document's redaction, participant resolver
+// and body size limit applied, separating them by a blank line
+func (r *Document) renderEach(render func(*Diagram) (string, error)) (string, error) {
+	var blocks []string
+	for _, d := range r.diagrams() {
+		block, err := render(d)
+		if err != nil {
+			return "", err
+		}
+		blocks = append(blocks, block)
+	}
+	return strings.Join(blocks, "\n"), nil
+}
+
+// clone returns a copy of the diagram with the participants and events added so far
+func (r *Diagram) clone() *Diagram {
+	participants, events := r.recorded()
+	return &Diagram{Title: r.Title, SubTitle: r.SubTitle, Participants: participants, Events: events, Redaction: r.Redaction,
+		Resolver: r.Resolver, MaxBodySize: r.MaxBodySize}
 }
 
 func (r *Diagram) AddTitle(title string) *Diagram {
@@ -134,18 +264,24 @@ func (r *Diagram) AddSubTitle(subTitle string) *Diagram {
 }
 
 func (r *Diagram) responseStatus() (int, error) {
-	if len(r.Events) == 0 {
+	_, events := r.recorded()
+	if len(events) == 0 {
 		return -1, errors.New("no events are defined")
 	}
 
-	switch v := r.Events[len(r.Events)-1].(type) {
-	case HttpResponse:
-		return v.Value.StatusCode, nil
-	case MessageResponse:
-		return -1, nil
-	default:
+	// notes and dividers may follow the final response
+	last := events[len(events)-1]
+	for i := len(events) - 2; i >= 0 && isAnnotation(last); i-- {
+		last = events[i]
+	}
+	if last == nil || !last.IsResponse() {
 		return -1, errors.New("final event should be a response type")
 	}
+
+	if v, ok := last.(StatusCoder); ok {
+		return v.StatusCode(), nil
+	}
+	return -1, nil
 }
 
 func badgeCSSClass(status int) string {
@@ -160,55 +296,76 @@ func badgeCSSClass(status int) string {
 
 func (r *Document) BuildModel() (DocumentHtmlModel, error) {
 	var diagrams []DiagramHtmlModel
-	for _, d := range r.Diagrams {
-		model, err := d.BuildModel()
+	for i, d := range r.diagrams() {
+		model, err := d.buildModel(r.storeBody(i + 1))
 		if err != nil {
 			return DocumentHtmlModel{}, err
 		}
+		if r.StaticSVG {
+			// ids are prefixed with the id of the diagram's element, since every image is inlined in one page
+			svg, err := d.renderSVG(fmt.Sprintf("d%d-", i))
+			if err != nil {
+				return DocumentHtmlModel{}, err
+			}
+			model.SVG = template.HTML(svg)
+		}
 		diagrams = append(diagrams, model)
 	}
 
+	stylesheets, scripts, err := buildAssetModels(r.EmbeddedAssets)
+	if err != nil {
+		return DocumentHtmlModel{}, err
+	}
+
 	return DocumentHtmlModel{
 		Title:       r.Title,
 		Description: r.Description,
 		Diagrams:    diagrams,
 		MetaJSON:    r.MetaJSON,
+		Stylesheets: stylesheets,
+		Scripts:     scripts,
 	}, nil
 }
 
 func (r *Diagram) BuildModel() (DiagramHtmlModel, error) {
-	if len(r.Events) == 0 {
+	return r.buildModel(nil)
+}
+
+// buildModel builds the model of the diagram. Bodies longer than MaxBodySize are truncated, and the full
+// bodies are passed to storeBody when it is set so the log entry can link to them
+func (r *Diagram) buildModel(storeBody func(entry int, body string) (string, error)) (DiagramHtmlModel, error) {
+	if _, events := r.recorded(); len(events) == 0 {
 		return DiagramHtmlModel{}, errors.New("no events are defined")
 	}
 
-	var logs []LogEntry
 	webSequenceDiagram := &WebSequenceDiagram{}
-	for _, event := range r.Events {
-		switch v := event.(type) {
-		case HttpRequest:
-			httpReq := v.Value
-			webSequenceDiagram.AddRequestRow(v.Source, v.Target, fmt.Sprintf("%s %s", httpReq.Method, httpReq.URL))
-			entry, err := newHttpRequestLogModel(httpReq)
+	if err := r.writeDSL(webSequenceDiagram); err != nil {
+		return DiagramHtmlModel{}, err
+	}
+
+	events, err := r.events()
+	if err != nil {
+		return DiagramHtmlModel{}, err
+	}
+	var logs []LogEntry
+	for i, event := range events {
+		entry, truncated, err := limitedLogEntry(event, r.MaxBodySize)
+		if err != nil {
+			return DiagramHtmlModel{}, err
+		}
+		if truncated && storeBody != nil {
+			full, err := event.LogEntry()
 			if err != nil {
 				return DiagramHtmlModel{}, err
 			}
-			logs = append(logs, entry)
-		case HttpResponse:
-			webSequenceDiagram.AddResponseRow(v.Source, v.Target, strconv.Itoa(v.Value.StatusCode))
-			entry, err := newHttpResponseLogModel(v.Value)
-			if err != nil {
+			if entry.BodyURL, err = storeBody(i+1, full.Body); err != nil {
 				return DiagramHtmlModel{}, err
 			}
-			logs = append(logs, entry)
-		case MessageRequest:
-			webSequenceDiagram.AddRequestRow(v.Source, v.Target, v.Header)
-			logs = append(logs, LogEntry{Header: v.Header, Body: v.Body})
-		case MessageResponse:
-			webSequenceDiagram.AddResponseRow(v.Source, v.Target, v.Header)
-			logs = append(logs, LogEntry{Header: v.Header, Body: v.Body})
-		default:
-			panic("received unknown event type")
 		}
+		if duration, ok := eventDuration(event); ok {
+			entry.Latency = formatDuration(duration)
+		}
+		logs = append(logs, entry)
 	}
 
 	status, err := r.responseStatus()
@@ -217,14 +374,96 @@ func (r *Diagram) BuildModel() (DiagramHtmlModel, error) {
 	}
 
 	return DiagramHtmlModel{
-		LogEntries: logs,
-		Title:      r.Title,
-		SubTitle:   r.SubTitle,
-		StatusCode: status,
-		BadgeClass: badgeCSSClass(status),
+		WebSequenceDSL: webSequenceDiagram.ToString(),
+		LogEntries:     logs,
+		Title:          r.Title,
+		SubTitle:       r.SubTitle,
+		StatusCode:     status,
+		BadgeClass:     badgeCSSClass(status),
+		TotalTime:      totalTime(events),
 	}, nil
 }
 
+// dslBuilder is implemented by each text based sequence diagram syntax a Diagram can be written to
+type dslBuilder interface {
+	AddParticipant(participant Participant)
+	AddRequestRow(source, target, description string)
+	AddResponseRow(source, target, description string)
+	AddNote(position NotePosition, participants []string, text string)
+	AddDivider(text string)
+	AddGroupStart(kind GroupKind, label string)
+	AddGroupBranch(kind GroupKind, label string)
+	AddGroupEnd(kind GroupKind)
+	ToString() string
+}
+
+func (r *Diagram) writeDSL(builder dslBuilder) error {
+	events, err := r.events()
+	if err != nil {
+		return err
+	}
+	for i, participant := range r.participants() {
+		if participant.ID == "" {
+			return fmt.Errorf("participant %d has no id", i+1)
+		}
+		// colors are written into the DSLs as they are, so anything else could inject statements
+		if participant.Color != "" && !participantColor.MatchString(participant.Color) {
+			return fmt.Errorf("participant %d has invalid color %q, use a CSS color name or hex code", i+1, participant.Color)
+		}
+		builder.AddParticipant(participant)
+	}
+
+	var groups groupStack
+	for i, event := range events {
+		if event == nil {
+			return fmt.Errorf("event %d is nil", i+1)
+		}
+
+		switch v := event.(type) {
+		case Note:
+			if err := v.validate(); err != nil {
+				return fmt.Errorf("event %d: %v", i+1, err)
+			}
+			builder.AddNote(v.position(), v.span(), v.Text)
+			continue
+		case Divider:
+			builder.AddDivider(v.Text)
+			continue
+		case GroupStart:
+			if err := groups.start(v); err != nil {
+				return fmt.Errorf("event %d: %v", i+1, err)
+			}
+			builder.AddGroupStart(v.Kind, v.Text)
+			continue
+		case GroupBranch:
+			kind, err := groups.branch()
+			if err != nil {
+				return fmt.Errorf("event %d: %v", i+1, err)
+			}
+			builder.AddGroupBranch(kind, v.Text)
+			continue
+		case GroupEnd:
+			kind, err := groups.end()
+			if err != nil {
+				return fmt.Errorf("event %d: %v", i+1, err)
+			}
+			builder.AddGroupEnd(kind)
+			continue
+		}
+
+		label := event.Label()
+		if duration, ok := eventDuration(event); ok {
+			label = fmt.Sprintf("%s [%s]", label, formatDuration(duration))
+		}
+		if event.IsResponse() {
+			builder.AddResponseRow(event.From(), event.To(), label)
+		} else {
+			builder.AddRequestRow(event.From(), event.To(), label)
+		}
+	}
+	return groups.balanced()
+}
+
 func (r *Document) RenderHTML() (string, error) {
 	htmlModel, err := r.BuildModel()
 	if err != nil {
@@ -232,7 +471,7 @@ func (r *Document) RenderHTML() (string, error) {
 	}
 
 	tmpl, err := template.New("sequenceDiagram").
-		Funcs(*incTemplateFunc).
+		Funcs(*templateFuncs).
 		Parse(t)
 	if err != nil {
 		return "", err
@@ -247,61 +486,219 @@ func (r *Document) RenderHTML() (string, error) {
 	return out.String(), nil
 }
 
-func newHttpRequestLogModel(req *http.Request) (LogEntry, error) {
+func (r HttpRequest) From() string { return r.Source }
+
+func (r HttpRequest) To() string { return r.Target }
+
+func (r HttpRequest) IsResponse() bool { return false }
+
+func (r HttpRequest) Label() string {
+	if r.Value == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s %s", r.Value.Method, r.Value.URL)
+}
+
+func (r HttpRequest) LogEntry() (LogEntry, error) {
+	if r.Value == nil {
+		return LogEntry{}, errors.New("http request event has no request")
+	}
+	entry, _, err := newHttpRequestLogModel(r.Value, 0)
+	return entry, err
+}
+
+func (r HttpRequest) limitedLogEntry(max int) (LogEntry, bool, error) {
+	if r.Value == nil {
+		return LogEntry{}, false, errors.New("http request event has no request")
+	}
+	return newHttpRequestLogModel(r.Value, max)
+}
+
+func (r HttpRequest) Timing() (time.Time, time.Time) { return r.Start, r.End }
+
+func (r HttpResponse) From() string { return r.Source }
+
+func (r HttpResponse) To() string { return r.Target }
+
+func (r HttpResponse) IsResponse() bool { return true }
+
+func (r HttpResponse) Label() string {
+	if r.Value == nil {
+		return ""
+	}
+	return strconv.Itoa(r.Value.StatusCode)
+}
+
+func (r HttpResponse) StatusCode() int {
+	if r.Value == nil {
+		return -1
+	}
+	return r.Value.StatusCode
+}
+
+func (r HttpResponse) LogEntry() (LogEntry, error) {
+	if r.Value == nil {
+		return LogEntry{}, errors.New("http response event has no response")
+	}
+	entry, _, err := newHttpResponseLogModel(r.Value, 0)
+	return entry, err
+}
+
+func (r HttpResponse) limitedLogEntry(max int) (LogEntry, bool, error) {
+	if r.Value == nil {
+		return LogEntry{}, false, errors.New("http response event has no response")
+	}
+	return newHttpResponseLogModel(r.Value, max)
+}
+
+func (r HttpResponse) Timing() (time.Time, time.Time) { return r.Start, r.End }
+
+func (r MessageRequest) From() string { return r.Source }
+
+func (r MessageRequest) To() string { return r.Target }
+
+func (r MessageRequest) IsResponse() bool { return false }
+
+func (r MessageRequest) Label() string { return r.Header }
+
+func (r MessageRequest) LogEntry() (LogEntry, error) {
+	return newMessageLogModel(r.Header, r.Body)
+}
+
+func (r MessageRequest) Timing() (time.Time, time.Time) { return r.Start, r.End }
+
+func (r MessageResponse) From() string { return r.Source }
+
+func (r MessageResponse) To() string { return r.Target }
+
+func (r MessageResponse) IsResponse() bool { return true }
+
+func (r MessageResponse) Label() string { return r.Header }
+
+func (r MessageResponse) LogEntry() (LogEntry, error) {
+	return newMessageLogModel(r.Header, r.Body)
+}
+
+func (r MessageResponse) Timing() (time.Time, time.Time) { return r.Start, r.End }
+
+// newMessageLogModel shows message bodies as they were given, highlighting those that hold JSON
+func newMessageLogModel(header, body string) (LogEntry, error) {
+	return LogEntry{Header: header, Body: body, Language: bodyFormatterFor("", []byte(body)).Language}, nil
+}
+
+// newHttpRequestLogModel builds the log entry of a request, keeping at most max bytes of its body when
+// max is positive. It reports whether the body was cut
+func newHttpRequestLogModel(req *http.Request, max int) (LogEntry, bool, error) {
 	reqHeader, err := httputil.DumpRequestOut(req, false)
 	if err != nil {
-		return LogEntry{}, err
+		return LogEntry{}, false, err
+	}
+	var body []byte
+	body, req.Body, err = readBody(req.Body)
+	if err != nil {
+		return LogEntry{}, false, err
 	}
-	body, err := formatContent(req.Body, req.Header.Get("Content-Type"))
+	formatted, language, truncated, err := formatHttpBody(body, req.Header, max)
 	if err != nil {
-		return LogEntry{}, err
+		return LogEntry{}, false, err
 	}
-	return LogEntry{Header: string(reqHeader), Body: body}, err
+	return LogEntry{Header: string(reqHeader), Body: formatted, Language: language}, truncated, nil
 }
 
-func newHttpResponseLogModel(res *http.Response) (LogEntry, error) {
+// newHttpResponseLogModel builds the log entry of a response, keeping at most max bytes of its body when
+// max is positive. It reports whether the body was cut
+func newHttpResponseLogModel(res *http.Response, max int) (LogEntry, bool, error) {
 	resDump, err := httputil.DumpResponse(res, false)
 	if err != nil {
-		return LogEntry{}, err
+		return LogEntry{}, false, err
+	}
+	var body []byte
+	body, res.Body, err = readBody(res.Body)
+	if err != nil {
+		return LogEntry{}, false, err
 	}
-	body, err := formatContent(res.Body, res.Header.Get("Content-Type"))
+	formatted, language, truncated, err := formatHttpBody(body, res.Header, max)
 	if err != nil {
-		return LogEntry{}, err
+		return LogEntry{}, false, err
 	}
-	return LogEntry{Header: string(resDump), Body: body}, err
+	return LogEntry{Header: string(resDump), Body: formatted, Language: language}, truncated, nil
 }
 
-func formatContent(bodyReadCloser io.ReadCloser, contentType string) (string, error) {
-	if bodyReadCloser == nil {
-		return "", nil
+// drainBody reads all of b into memory and returns two equivalent readers, so the
+// body can be rendered more than once and still be read by the caller afterwards
+func drainBody(b io.ReadCloser) (io.ReadCloser, io.ReadCloser, error) {
+	if b == nil || b == http.NoBody {
+		return b, b, nil
+	}
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(b); err != nil {
+		return nil, b, err
+	}
+	if err := b.Close(); err != nil {
+		return nil, b, err
 	}
+	return ioutil.NopCloser(&buf), ioutil.NopCloser(bytes.NewReader(buf.Bytes())), nil
+}
 
-	body, err := ioutil.ReadAll(bodyReadCloser)
-	if err != nil {
-		return "", err
+// recordingBody passes a body through to its reader while recording what is read. done is called once
+// with the recorded content, when the body is read to the end or closed, whichever happens first
+type recordingBody struct {
+	body io.ReadCloser
+	buf  bytes.Buffer
+	once sync.Once
+	done func(body []byte)
+}
+
+func newRecordingBody(b io.ReadCloser, done func(body []byte)) *recordingBody {
+	return &recordingBody{body: b, done: done}
+}
+
+func (r *recordingBody) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	r.buf.Write(p[:n])
+	if err == io.EOF {
+		r.finish()
 	}
+	return n, err
+}
 
-	buf := new(bytes.Buffer)
-	if contentType == "application/json" {
-		if len(body) > 0 {
-			err := json.Indent(buf, body, "", "    ")
-			if err != nil {
-				return "", err
-			}
-		}
-		return "", nil
-	} else {
-		_, err := buf.Write(body)
-		if err != nil {
-			return "", err
-		}
+func (r *recordingBody) Close() error {
+	err := r.body.Close()
+	r.finish()
+	return err
+}
+
+func (r *recordingBody) finish() {
+	r.once.Do(func() { r.done(r.buf.Bytes()) })
+}
+
+// readBody reads all of b into memory and returns its content along with a reader that replaces b
+func readBody(b io.ReadCloser) ([]byte, io.ReadCloser, error) {
+	if b == nil || b == http.NoBody {
+		return nil, b, nil
+	}
+	body, err := ioutil.ReadAll(b)
+	if err != nil {
+		return nil, b, err
 	}
+	if err := b.Close(); err != nil {
+		return nil, b, err
+	}
+	return body, ioutil.NopCloser(bytes.NewReader(body)), nil
+}
 
-	return buf.String(), nil
+var templateFuncs = &template.FuncMap{
+	"arrowNumber": arrowNumber,
 }
 
-var incTemplateFunc = &template.FuncMap{
-	"inc": func(i int) int {
-		return i + 1
-	},
+// arrowNumber returns the number of the arrow drawn for the log entry at index. Annotations are not
+// numbered, so arrows are numbered 1, 2, 3 regardless of the notes, dividers and groups between them
+func arrowNumber(entries []LogEntry, index int) int {
+	number := 0
+	for _, entry := range entries[:index+1] {
+		if entry.Annotation == "" {
+			number++
+		}
+	}
+	return number
 }
@@ -3,9 +3,7 @@ package sequence
 import (
 	"bytes"
 	"github.com/stretchr/testify/assert"
-	"io/ioutil"
 	"net/http"
-	"strings"
 	"testing"
 )
 
@@ -91,6 +89,58 @@ func TestDiagram_BuildModel_ErrorIfResponseTypeNotFinalEvent(t *testing.T) {
 	assert.EqualError(t, err, "final event should be a response type")
 }
 
+func TestDiagram_AddEvent_SupportsCustomEventTypes(t *testing.T) {
+	model, err := NewDiagram().
+		AddEvent(kafkaPublish{topic: "posts", payload: `{"id":1}`}).
+		AddMessageResponse(MessageResponse{Source: "kafka", Target: "app", Header: "ack"}).
+		BuildModel()
+
+	assert.Nil(t, err)
+	assert.Equal(t, LogEntry{Header: "publish posts", Body: `{"id":1}`}, model.LogEntries[0])
+	assert.Equal(t, -1, model.StatusCode)
+}
+
+func TestDiagram_AddEvent_WhileRendering(t *testing.T) {
+	diagram := NewDiagram().
+		AddMessageRequest(MessageRequest{Source: "cli", Target: "app", Header: "ping"}).
+		AddMessageResponse(MessageResponse{Source: "app", Target: "cli", Header: "pong"})
+	document := NewDocument().AddDiagram(diagram).AddRedaction(DefaultRedaction())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			diagram.AddMessageRequest(MessageRequest{Source: "cli", Target: "app", Header: "ping"}).
+				AddMessageResponse(MessageResponse{Source: "app", Target: "cli", Header: "pong"})
+		}
+	}()
+
+	for i := 0; i < 20; i++ {
+		_, err := document.RenderHTML()
+		assert.Nil(t, err)
+		_, err = diagram.MarshalJSON()
+		assert.Nil(t, err)
+		_, err = document.ToHAR()
+		assert.Nil(t, err)
+	}
+	<-done
+
+	model, err := diagram.BuildModel()
+	assert.Nil(t, err)
+	assert.Len(t, model.LogEntries, 202)
+}
+
+func TestDiagram_BuildModel_ErrorIfEventIsNil(t *testing.T) {
+	_, err := NewDiagram().AddEvent(nil).AddHttpResponse(aResponse()).BuildModel()
+
+	assert.EqualError(t, err, "event 1 is nil")
+}
+
+func TestDiagram_BuildModel_ErrorIfHttpResponseHasNoValue(t *testing.T) {
+	_, err := aDiagram().AddHttpResponse(HttpResponse{}).BuildModel()
+
+	assert.EqualError(t, err, "http response event has no response")
+}
+
 func TestDiagram_SetsResponseStatus(t *testing.T) {
 	aResponse := HttpResponse{Value: &http.Response{StatusCode: http.StatusNoContent}}
 
@@ -117,31 +167,36 @@ func TestDiagram_BadgeCSSClass(t *testing.T) {
 	}
 }
 
-func TestFormatContent_PrettyPrintsJSON(t *testing.T) {
-	buffer := ioutil.NopCloser(strings.NewReader(`{"a":"b"}`))
+func TestDiagram_BuildModel_SetsWebSequenceDSL(t *testing.T) {
+	model, err := NewDiagram().
+		AddMessageRequest(MessageRequest{Source: "cli", Target: "app", Header: "ping"}).
+		AddMessageResponse(MessageResponse{Source: "app", Target: "cli", Header: "pong"}).
+		BuildModel()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "cli->app: (1) ping\napp->>cli: (2) pong\n", model.WebSequenceDSL)
+}
 
-	content, err := formatContent(buffer, "application/json")
+func TestFormatHttpBody_PrettyPrintsJSON(t *testing.T) {
+	content, _, _, err := formatHttpBody([]byte(`{"a":"b"}`), http.Header{"Content-Type": []string{"application/json"}}, 0)
 
 	assert.Nil(t, err)
 	assert.Equal(t, "{\n    \"a\": \"b\"\n}", content)
 }
 
-func TestFormatContent_FormatsPlainText(t *testing.T) {
-	buffer := ioutil.NopCloser(strings.NewReader(`abcdef`))
-
-	content, err := formatContent(buffer, "text/plain")
+func TestFormatHttpBody_FormatsPlainText(t *testing.T) {
+	content, _, _, err := formatHttpBody([]byte(`abcdef`), http.Header{"Content-Type": []string{"text/plain"}}, 0)
 
 	assert.Nil(t, err)
 	assert.Equal(t, "abcdef", content)
 }
 
-func TestFormatContent_HandlesEmptyBody(t *testing.T) {
-	buffer := ioutil.NopCloser(strings.NewReader(""))
-
-	content, err := formatContent(buffer, "application/json")
+func TestFormatBody_HandlesEmptyBody(t *testing.T) {
+	content, language, err := formatBody(nil, "application/json")
 
 	assert.Nil(t, err)
 	assert.Equal(t, "", content)
+	assert.Equal(t, "", language)
 }
 
 func TestDocument_SupportsMultipleDiagrams(t *testing.T) {
@@ -203,6 +258,36 @@ func TestDocument_AddsMeta(t *testing.T) {
 	assert.Contains(t, html, `<script type="application/json" id="metaJson">{"a": 123}</script>`)
 }
 
+func TestDocument_RenderHTML_DrawsEachDiagramInItsOwnElement(t *testing.T) {
+	document := NewDocument().
+		AddDiagram(NewDiagram().
+			AddMessageRequest(MessageRequest{Source: "cli", Target: "app", Header: "first"}).
+			AddMessageResponse(MessageResponse{Source: "app", Target: "cli", Header: "ok"})).
+		AddDiagram(NewDiagram().
+			AddMessageRequest(MessageRequest{Source: "cli", Target: "app", Header: "second"}).
+			AddMessageResponse(MessageResponse{Source: "app", Target: "cli", Header: "ok"}))
+
+	html, err := document.RenderHTML()
+
+	assert.Nil(t, err)
+	assert.Contains(t, html, `<div id="d0" class="justify-content-center">`)
+	assert.Contains(t, html, `<div id="d1" class="justify-content-center">`)
+	assert.Contains(t, html, `Diagram.parse("cli-\u003eapp: (1) first\napp-\u003e\u003ecli: (2) ok\n").drawSVG("d0",`)
+	assert.Contains(t, html, `Diagram.parse("cli-\u003eapp: (1) second\napp-\u003e\u003ecli: (2) ok\n").drawSVG("d1",`)
+}
+
+func TestDocument_RenderHTML_EscapesWebSequenceDSL(t *testing.T) {
+	document := NewDocument().AddDiagram(NewDiagram().
+		AddMessageRequest(MessageRequest{Source: "cli", Target: "app", Header: `say "hi"</script><script>alert(1)`}).
+		AddMessageResponse(MessageResponse{Source: "app", Target: "cli", Header: "ok"}))
+
+	html, err := document.RenderHTML()
+
+	assert.Nil(t, err)
+	assert.NotContains(t, html, `</script><script>alert(1)`)
+	assert.Contains(t, html, `say \u0022hi\u0022\u003c\/script\u003e\u003cscript\u003ealert(1)`)
+}
+
 func aDiagram() *Diagram {
 	return NewDiagram().
 		AddHttpRequest(aRequest()).
@@ -218,3 +303,16 @@ func aRequest() HttpRequest {
 func aResponse() HttpResponse {
 	return HttpResponse{Value: &http.Response{StatusCode: http.StatusNoContent}}
 }
+
+type kafkaPublish struct {
+	topic   string
+	payload string
+}
+
+func (k kafkaPublish) From() string     { return "app" }
+func (k kafkaPublish) To() string       { return "kafka" }
+func (k kafkaPublish) Label() string    { return "publish " + k.topic }
+func (k kafkaPublish) IsResponse() bool { return false }
+func (k kafkaPublish) LogEntry() (LogEntry, error) {
+	return LogEntry{Header: k.Label(), Body: k.payload}, nil
+}
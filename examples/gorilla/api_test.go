@@ -2,56 +2,122 @@ package main
 
 import (
 	"github.com/h2non/gock"
+	"github.com/steinfletcher/sequence-diagrams/apitest"
+	"github.com/stretchr/testify/assert"
+	"log"
 	"net/http"
+	"os"
 	"testing"
 )
 
+var report = apitest.NewReport("Posts API")
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if err := report.WriteFile("sequence_diagrams.html"); err != nil {
+		log.Fatal(err)
+	}
+	os.Exit(code)
+}
+
+func newApiTest() *apitest.ApiTest {
+	return apitest.New(NewApp().Router).
+		WithMocks(&gockMocks{}).
+		WithReport(report)
+}
+
 func TestApi_GetPosts(t *testing.T) {
-	testCase := TestCase{
+	newApiTest().Run(t, apitest.TestCase{
+		Name:                   "gets posts",
 		RequestURL:             "/post",
 		RequestMethod:          http.MethodGet,
 		ExpectedResponseStatus: http.StatusOK,
 		ExpectedResponseBody:   `{"userId":1, "title": "go rulez", "body": "say no more"}`,
-		Before: func(test *ApiTest) {
+		Before: func(test *apitest.ApiTest) {
 			gock.New("http://example.com").
 				Get("/posts").
 				Reply(http.StatusOK).
 				BodyString(`{"userId":1, "title": "go rulez", "body": "say no more"}`)
 		},
-	}
-
-	NewApiTest(NewApp()).Run(t, testCase)
+	})
 }
 
 func TestApi_DeletePost(t *testing.T) {
-	testCase := TestCase{
+	newApiTest().Run(t, apitest.TestCase{
+		Name:                   "deletes a post",
 		RequestURL:             "/post/1",
 		RequestMethod:          http.MethodDelete,
 		ExpectedResponseStatus: http.StatusNoContent,
-		Before: func(test *ApiTest) {
+		Before: func(test *apitest.ApiTest) {
 			gock.New("http://example.com").
 				Delete("/posts/1").
 				Reply(http.StatusNoContent)
 		},
-	}
-
-	NewApiTest(NewApp()).Run(t, testCase)
+	})
 }
 
 func TestApi_CreatePost(t *testing.T) {
-	testCase := TestCase{
+	newApiTest().Run(t, apitest.TestCase{
+		Name:                   "creates a post",
 		RequestURL:             "/post",
 		RequestMethod:          http.MethodPost,
 		RequestBody:            `{"userId":1, "title": "go rulez", "body": "say no more"}`,
 		ExpectedResponseStatus: http.StatusCreated,
-		Before: func(test *ApiTest) {
+		Before: func(test *apitest.ApiTest) {
 			gock.New("http://example.com").
 				Post("/posts").
 				MatchHeader("Content-Type", "application/json").
 				BodyString(`{"userId":1, "title": "go rulez", "body": "say no more"}`).
 				Reply(http.StatusCreated)
 		},
-	}
+	})
+}
+
+func TestApi_RecordsOutboundCalls(t *testing.T) {
+	posts := apitest.NewReport("Posts API")
+
+	apitest.New(NewApp().Router).
+		WithMocks(&gockMocks{}).
+		WithReport(posts).
+		Run(t, apitest.TestCase{
+			Name:                   "gets posts",
+			RequestURL:             "/post",
+			RequestMethod:          http.MethodGet,
+			ExpectedResponseStatus: http.StatusOK,
+			Before: func(test *apitest.ApiTest) {
+				gock.New("http://example.com").
+					Get("/posts").
+					Reply(http.StatusOK).
+					BodyString(`{"userId":1}`)
+			},
+		})
+
+	events := posts.Document.Diagrams[0].Events
+	assert.Len(t, events, 4)
+	assert.Equal(t, "example.com", events[1].To())
+	assert.Equal(t, "example.com", events[2].From())
+}
+
+func TestApi_RecordsOutboundResponsesTheHandlerDoesNotRead(t *testing.T) {
+	posts := apitest.NewReport("Posts API")
+
+	apitest.New(NewApp().Router).
+		WithMocks(&gockMocks{}).
+		WithReport(posts).
+		Run(t, apitest.TestCase{
+			Name:                   "deletes a post",
+			RequestURL:             "/post/1",
+			RequestMethod:          http.MethodDelete,
+			ExpectedResponseStatus: http.StatusNoContent,
+			Before: func(test *apitest.ApiTest) {
+				gock.New("http://example.com").
+					Delete("/posts/1").
+					Reply(http.StatusNoContent)
+			},
+		})
 
-	NewApiTest(NewApp()).Run(t, testCase)
+	events := posts.Document.Diagrams[0].Events
+	assert.Len(t, events, 4)
+	assert.Equal(t, "example.com", events[2].From())
+	assert.Equal(t, "consumer", events[3].To())
 }
@@ -1,10 +1,14 @@
 module gorilla
 
 require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/gorilla/context v1.1.1 // indirect
 	github.com/gorilla/mux v1.6.2
 	github.com/h2non/gock v1.0.12
 	github.com/nbio/st v0.0.0-20140626010706-e9e8d9816f32 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/steinfletcher/sequence-diagrams v0.0.0-20181216155943-8362d7a2c1a9
-	github.com/stretchr/testify v1.2.2
+	github.com/stretchr/testify v1.2.2 // indirect
 )
+
+replace github.com/steinfletcher/sequence-diagrams => ../..
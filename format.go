@@ -0,0 +1,214 @@
+package sequence
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"mime"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// BodyFormatter formats a captured body for the log table of a report
+type BodyFormatter struct {
+	// Format returns the body for display
+	Format func(body []byte) (string, error)
+	// Language is the highlight.js language used to highlight the formatted body. Bodies without a
+	// language are not highlighted
+	Language string
+}
+
+var bodyFormatters = struct {
+	sync.RWMutex
+	byMediaType map[string]BodyFormatter
+}{byMediaType: map[string]BodyFormatter{
+	"application/json":                  {Format: formatJSON, Language: "json"},
+	"application/*+json":                {Format: formatJSON, Language: "json"},
+	"application/x-ndjson":              {Format: formatText, Language: "json"},
+	"application/xml":                   {Format: formatXML, Language: "xml"},
+	"application/*+xml":                 {Format: formatXML, Language: "xml"},
+	"text/xml":                          {Format: formatXML, Language: "xml"},
+	"text/html":                         {Format: formatText, Language: "xml"},
+	"application/x-www-form-urlencoded": {Format: formatForm},
+	"application/yaml":                  {Format: formatText, Language: "yaml"},
+	"application/x-yaml":                {Format: formatText, Language: "yaml"},
+	"text/yaml":                         {Format: formatText, Language: "yaml"},
+	"text/x-yaml":                       {Format: formatText, Language: "yaml"},
+	"text/*":                            {Format: formatText},
+}}
+
+// RegisterBodyFormatter sets the formatter used for bodies of the given media type, replacing any
+// registered before. Besides exact media types such as application/json, the key may name every type
+// with a structured syntax suffix, as in application/*+json, or every subtype, as in text/*
+func RegisterBodyFormatter(mediaType string, formatter BodyFormatter) {
+	bodyFormatters.Lock()
+	defer bodyFormatters.Unlock()
+	bodyFormatters.byMediaType[strings.ToLower(mediaType)] = formatter
+}
+
+// formatBody formats the body using the formatter registered for the content type. Bodies without a
+// content type that hold a JSON object or array are formatted as JSON. Binary bodies are summarised
+// and multipart bodies are shown part by part
+func formatBody(body []byte, contentType string) (string, string, error) {
+	if len(body) == 0 {
+		return "", "", nil
+	}
+	if boundary, ok := multipartBoundary(contentType); ok {
+		if formatted, err := formatMultipart(body, boundary); err == nil {
+			return formatted, "", nil
+		}
+	}
+	if isBinary(body) {
+		return binarySummary(body), "", nil
+	}
+	formatter := bodyFormatterFor(contentType, body)
+	formatted, err := formatter.Format(body)
+	return formatted, formatter.Language, err
+}
+
+func bodyFormatterFor(contentType string, body []byte) BodyFormatter {
+	bodyFormatters.RLock()
+	defer bodyFormatters.RUnlock()
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		if trimmed := bytes.TrimSpace(body); contentType == "" && len(trimmed) > 0 &&
+			(trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+			return bodyFormatters.byMediaType["application/json"]
+		}
+		return BodyFormatter{Format: formatText}
+	}
+
+	candidates := []string{mediaType}
+	if slash := strings.Index(mediaType, "/"); slash > 0 {
+		if plus := strings.LastIndex(mediaType, "+"); plus > slash {
+			candidates = append(candidates, mediaType[:slash]+"/*"+mediaType[plus:])
+		}
+		candidates = append(candidates, mediaType[:slash]+"/*")
+	}
+	for _, candidate := range candidates {
+		if formatter, ok := bodyFormatters.byMediaType[candidate]; ok {
+			return formatter
+		}
+	}
+	return BodyFormatter{Format: formatText}
+}
+
+func formatText(body []byte) (string, error) {
+	return string(body), nil
+}
+
+// formatJSON indents JSON bodies. Malformed JSON is shown as it was sent
+func formatJSON(body []byte) (string, error) {
+	var out bytes.Buffer
+	if err := json.Indent(&out, body, "", "    "); err != nil {
+		return string(body), nil
+	}
+	return out.String(), nil
+}
+
+// formatForm lists each decoded form field on its own line in the order it was sent
+func formatForm(body []byte) (string, error) {
+	var lines []string
+	for _, field := range strings.Split(string(body), "&") {
+		if field == "" {
+			continue
+		}
+		key, value := field, ""
+		if i := strings.Index(field, "="); i >= 0 {
+			key, value = field[:i], field[i+1:]
+		}
+		decodedKey, err := url.QueryUnescape(key)
+		if err != nil {
+			return string(body), nil
+		}
+		decodedValue, err := url.QueryUnescape(value)
+		if err != nil {
+			return string(body), nil
+		}
+		lines = append(lines, decodedKey+": "+decodedValue)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// formatXML indents XML bodies, such as SOAP envelopes, keeping namespace prefixes as they were sent.
+// Elements that only hold text are kept on one line. Malformed XML is shown as it was sent
+func formatXML(body []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	var out bytes.Buffer
+	depth := 0
+	// open is true while the last element written has not had any child nodes yet
+	open, inline := false, false
+	newline := func() {
+		if out.Len() > 0 {
+			out.WriteString("\n")
+		}
+		out.WriteString(strings.Repeat("    ", depth))
+	}
+
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return string(body), nil
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			newline()
+			out.WriteString("<" + xmlName(t.Name))
+			for _, attr := range t.Attr {
+				out.WriteString(" " + xmlName(attr.Name) + `="`)
+				xml.EscapeText(&out, []byte(attr.Value))
+				out.WriteString(`"`)
+			}
+			out.WriteString(">")
+			depth++
+			open, inline = true, false
+		case xml.EndElement:
+			depth--
+			if !open && !inline {
+				newline()
+			}
+			out.WriteString("</" + xmlName(t.Name) + ">")
+			open, inline = false, false
+		case xml.CharData:
+			text := bytes.TrimSpace(t)
+			if len(text) == 0 {
+				continue
+			}
+			if !open {
+				newline()
+			}
+			xml.EscapeText(&out, text)
+			open, inline = false, true
+		case xml.Comment:
+			newline()
+			out.WriteString("<!--" + string(t) + "-->")
+			open, inline = false, false
+		case xml.ProcInst:
+			newline()
+			out.WriteString("<?" + t.Target + " " + string(t.Inst) + "?>")
+			open, inline = false, false
+		case xml.Directive:
+			newline()
+			out.WriteString("<!" + string(t) + ">")
+			open, inline = false, false
+		}
+	}
+	if depth != 0 {
+		return string(body), nil
+	}
+	return out.String(), nil
+}
+
+func xmlName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
@@ -0,0 +1,65 @@
+package sequence
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFormatBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    string
+		language    string
+	}{
+		{name: "json with charset", contentType: "application/json; charset=utf-8", body: `{"a":1}`, expected: "{\n    \"a\": 1\n}", language: "json"},
+		{name: "problem json", contentType: "application/problem+json", body: `{"title":"x"}`, expected: "{\n    \"title\": \"x\"\n}", language: "json"},
+		{name: "malformed json", contentType: "application/json", body: `{"a":`, expected: `{"a":`, language: "json"},
+		{name: "sniffed json", contentType: "", body: `[1,2]`, expected: "[\n    1,\n    2\n]", language: "json"},
+		{name: "xml", contentType: "application/xml", body: `<?xml version="1.0"?><a x="1"><b>text &amp; more</b><c/></a>`,
+			expected: "<?xml version=\"1.0\"?>\n<a x=\"1\">\n    <b>text &amp; more</b>\n    <c></c>\n</a>", language: "xml"},
+		{name: "soap", contentType: "application/soap+xml", body: `<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope"><soap:Body><m:Ping xmlns:m="urn:ping"/></soap:Body></soap:Envelope>`,
+			expected: "<soap:Envelope xmlns:soap=\"http://www.w3.org/2003/05/soap-envelope\">\n    <soap:Body>\n        <m:Ping xmlns:m=\"urn:ping\"></m:Ping>\n    </soap:Body>\n</soap:Envelope>", language: "xml"},
+		{name: "malformed xml", contentType: "text/xml", body: `<a><b></a>`, expected: `<a><b></a>`, language: "xml"},
+		{name: "form", contentType: "application/x-www-form-urlencoded", body: "b=2+3&a=%2Fx&flag", expected: "b: 2 3\na: /x\nflag: "},
+		{name: "yaml", contentType: "application/x-yaml", body: "a: 1\n", expected: "a: 1\n", language: "yaml"},
+		{name: "plain text", contentType: "text/plain", body: "hello", expected: "hello"},
+		{name: "unknown", contentType: "application/octet-stream", body: "abc", expected: "abc"},
+		{name: "invalid content type", contentType: ";;", body: "abc", expected: "abc"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			formatted, language, err := formatBody([]byte(test.body), test.contentType)
+
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, formatted)
+			assert.Equal(t, test.language, language)
+		})
+	}
+}
+
+func TestRegisterBodyFormatter(t *testing.T) {
+	RegisterBodyFormatter("application/vnd.test+csv", BodyFormatter{
+		Format:   func(body []byte) (string, error) { return "csv:" + string(body), nil },
+		Language: "plaintext",
+	})
+
+	formatted, language, err := formatBody([]byte("a,b"), "application/vnd.test+csv")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "csv:a,b", formatted)
+	assert.Equal(t, "plaintext", language)
+}
+
+func TestDocument_RenderHTML_HighlightsBodiesByLanguage(t *testing.T) {
+	document := NewDocument().AddDiagram(NewDiagram().
+		AddMessageRequest(MessageRequest{Source: "cli", Target: "app", Header: "json", Body: `{"a": 1}`}).
+		AddMessageResponse(MessageResponse{Source: "app", Target: "cli", Header: "text", Body: "ok"}))
+
+	html, err := document.RenderHTML()
+
+	assert.Nil(t, err)
+	assert.Contains(t, html, `<code class="json">{&#34;a&#34;: 1}</code>`)
+	assert.Contains(t, html, `<code class="nohighlight">ok</code>`)
+}
@@ -1,7 +1,11 @@
 module github.com/steinfletcher/sequence-diagrams
 
+go 1.16
+
 require (
+	github.com/andybalholm/brotli v1.1.0
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/stretchr/testify v1.2.2
+	gopkg.in/yaml.v2 v2.2.2
 )
@@ -0,0 +1,197 @@
+package sequence
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GroupKind is the kind of a group fragment, which frames the events between its start and end
+type GroupKind string
+
+const (
+	// GroupAlt frames alternative branches, of which one happens
+	GroupAlt GroupKind = "alt"
+	// GroupOpt frames events that only happen when its condition holds
+	GroupOpt GroupKind = "opt"
+	// GroupLoop frames events that are repeated, such as retries
+	GroupLoop GroupKind = "loop"
+	// GroupPar frames branches that happen in parallel, such as fanned out calls
+	GroupPar GroupKind = "par"
+	// GroupCritical frames events that happen atomically, with optional branches for the circumstances
+	// in which they do not
+	GroupCritical GroupKind = "critical"
+)
+
+type (
+	// GroupStart starts a group fragment. Like notes, groups are shown as annotated rows of the log table
+	GroupStart struct {
+		Kind GroupKind
+		// Text labels the group, such as the condition of an alt or opt group
+		Text string
+	}
+
+	// GroupBranch starts another branch of an alt, par or critical group
+	GroupBranch struct {
+		Kind GroupKind
+		Text string
+	}
+
+	// GroupEnd ends the innermost group
+	GroupEnd struct {
+		Kind GroupKind
+	}
+)
+
+// BeginGroup starts a group fragment framing the events added until the matching EndGroup. Groups may
+// be nested. The diagram fails to render unless every group is ended
+func (r *Diagram) BeginGroup(kind GroupKind, label string) *Diagram {
+	return r.AddEvent(GroupStart{Kind: kind, Text: label})
+}
+
+// Else starts another branch of the innermost group, drawn as else in alt groups, and in par groups and
+// option in critical groups
+func (r *Diagram) Else(label string) *Diagram {
+	r.mu.Lock()
+	kind := r.openGroup()
+	r.mu.Unlock()
+	return r.AddEvent(GroupBranch{Kind: kind, Text: label})
+}
+
+// EndGroup ends the innermost group
+func (r *Diagram) EndGroup() *Diagram {
+	r.mu.Lock()
+	kind := r.openGroup()
+	r.mu.Unlock()
+	return r.AddEvent(GroupEnd{Kind: kind})
+}
+
+// openGroup returns the kind of the innermost group that has not ended
+func (r *Diagram) openGroup() GroupKind {
+	depth := 0
+	for i := len(r.Events) - 1; i >= 0; i-- {
+		switch v := r.Events[i].(type) {
+		case GroupEnd:
+			depth++
+		case GroupStart:
+			if depth == 0 {
+				return v.Kind
+			}
+			depth--
+		}
+	}
+	return ""
+}
+
+func (r GroupStart) From() string { return "" }
+
+func (r GroupStart) To() string { return "" }
+
+func (r GroupStart) IsResponse() bool { return false }
+
+func (r GroupStart) Label() string { return r.Text }
+
+func (r GroupStart) LogEntry() (LogEntry, error) {
+	return LogEntry{Header: r.Text, Annotation: capitalize(string(r.Kind))}, nil
+}
+
+func (r GroupBranch) From() string { return "" }
+
+func (r GroupBranch) To() string { return "" }
+
+func (r GroupBranch) IsResponse() bool { return false }
+
+func (r GroupBranch) Label() string { return r.Text }
+
+func (r GroupBranch) LogEntry() (LogEntry, error) {
+	return LogEntry{Header: r.Text, Annotation: capitalize(branchKeyword(r.Kind))}, nil
+}
+
+func (r GroupEnd) From() string { return "" }
+
+func (r GroupEnd) To() string { return "" }
+
+func (r GroupEnd) IsResponse() bool { return false }
+
+func (r GroupEnd) Label() string { return "" }
+
+func (r GroupEnd) LogEntry() (LogEntry, error) {
+	return LogEntry{Annotation: strings.TrimSpace("End " + string(r.Kind))}, nil
+}
+
+// branchKeyword returns the keyword that starts another branch of a group
+func branchKeyword(kind GroupKind) string {
+	switch kind {
+	case GroupPar:
+		return "and"
+	case GroupCritical:
+		return "option"
+	}
+	return "else"
+}
+
+// groupLine starts a group or branch in the Mermaid and PlantUML syntaxes, which take an optional label
+func groupLine(keyword, label string) string {
+	if label == "" {
+		return keyword
+	}
+	return keyword + " " + label
+}
+
+// groupTitle describes the start of a group or branch, such as "alt [cache hit]"
+func groupTitle(keyword, label string) string {
+	if label == "" {
+		return keyword
+	}
+	return fmt.Sprintf("%s [%s]", keyword, label)
+}
+
+func capitalize(text string) string {
+	if text == "" {
+		return text
+	}
+	return strings.ToUpper(text[:1]) + text[1:]
+}
+
+// groupStack checks that groups are balanced as a diagram is written
+type groupStack []GroupStart
+
+func (r *groupStack) start(group GroupStart) error {
+	switch group.Kind {
+	case GroupAlt, GroupOpt, GroupLoop, GroupPar, GroupCritical:
+	default:
+		return fmt.Errorf("group has unknown kind %q", group.Kind)
+	}
+	*r = append(*r, group)
+	return nil
+}
+
+// branch returns the kind of the group the branch belongs to
+func (r *groupStack) branch() (GroupKind, error) {
+	if len(*r) == 0 {
+		return "", fmt.Errorf("branch is not inside a group")
+	}
+	kind := (*r)[len(*r)-1].Kind
+	switch kind {
+	case GroupAlt, GroupPar, GroupCritical:
+		return kind, nil
+	}
+	return "", fmt.Errorf("%s group cannot have branches", kind)
+}
+
+// end returns the kind of the group that ended
+func (r *groupStack) end() (GroupKind, error) {
+	if len(*r) == 0 {
+		return "", fmt.Errorf("group end without a group")
+	}
+	kind := (*r)[len(*r)-1].Kind
+	*r = (*r)[:len(*r)-1]
+	return kind, nil
+}
+
+func (r groupStack) balanced() error {
+	if len(r) == 0 {
+		return nil
+	}
+	open := r[len(r)-1]
+	return fmt.Errorf("%s group %q is not ended", open.Kind, open.Text)
+}
@@ -0,0 +1,151 @@
+package sequence
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestDiagram_RenderMermaid_DrawsGroups(t *testing.T) {
+	mermaid, err := aGroupedDiagram().RenderMermaid()
+
+	assert.Nil(t, err)
+	assert.Equal(t, `sequenceDiagram
+    participant cli
+    participant app
+    participant db
+    participant cache
+    cli->>app: (1) GET /cart
+    par fan out
+    app->>db: (2) SELECT
+    and
+    loop retry up to 3 times
+    app->>cache: (3) GET cart
+    end
+    end
+    app-->>cli: (4) 200
+`, mermaid)
+}
+
+func TestDiagram_RenderPlantUML_DrawsGroups(t *testing.T) {
+	plantUML, err := aGroupedDiagram().RenderPlantUML()
+
+	assert.Nil(t, err)
+	assert.Equal(t, `@startuml
+participant cli
+participant app
+participant db
+participant cache
+cli -> app : (1) GET /cart
+par fan out
+app -> db : (2) SELECT
+else
+loop retry up to 3 times
+app -> cache : (3) GET cart
+end
+end
+app --> cli : (4) 200
+@enduml
+`, plantUML)
+}
+
+func TestDiagram_RenderWebSequenceDSL_DrawsGroupsAsNotes(t *testing.T) {
+	dsl, err := aGroupedDiagram().RenderWebSequenceDSL()
+
+	assert.Nil(t, err)
+	assert.Equal(t, `cli->app: (1) GET /cart
+Note over cli,cache: par [fan out]
+app->db: (2) SELECT
+Note over cli,cache: and
+Note over cli,cache: loop [retry up to 3 times]
+app->cache: (3) GET cart
+Note over cli,cache: end loop
+Note over cli,cache: end par
+app->>cli: (4) 200
+`, dsl)
+}
+
+func TestDiagram_RenderSVG_DrawsGroupsAsFrames(t *testing.T) {
+	svg, err := aGroupedDiagram().RenderSVG()
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, strings.Count(svg, `<rect class="group"`))
+	assert.Contains(t, svg, `<rect class="group" x="20" y="116" width="364" height="240" fill="none" stroke="black"/>`)
+	assert.Contains(t, svg, `<rect class="group" x="26" y="236" width="352" height="80" fill="none" stroke="black"/>`)
+	assert.Contains(t, svg, `<line class="group" x1="20" y1="196" x2="384" y2="196" stroke="black" stroke-dasharray="6,4"/>`)
+	assert.Contains(t, svg, ">[retry up to 3 times]</text>")
+}
+
+func TestDiagram_EndGroup_RecordsTheKindOfTheInnermostGroup(t *testing.T) {
+	diagram := aGroupedDiagram()
+
+	assert.Equal(t, GroupBranch{Kind: GroupPar}, diagram.Events[3])
+	assert.Equal(t, GroupEnd{Kind: GroupLoop}, diagram.Events[6])
+	assert.Equal(t, GroupEnd{Kind: GroupPar}, diagram.Events[7])
+}
+
+func TestDiagram_BuildModel_ShowsGroupsAsAnnotatedRows(t *testing.T) {
+	model, err := aGroupedDiagram().BuildModel()
+
+	assert.Nil(t, err)
+	assert.Equal(t, LogEntry{Header: "fan out", Annotation: "Par"}, model.LogEntries[1])
+	assert.Equal(t, LogEntry{Annotation: "And"}, model.LogEntries[3])
+	assert.Equal(t, LogEntry{Annotation: "End loop"}, model.LogEntries[6])
+}
+
+func TestDiagram_RenderMermaid_ValidatesGroups(t *testing.T) {
+	tests := []struct {
+		name     string
+		diagram  *Diagram
+		expected string
+	}{
+		{name: "not ended", diagram: aDiagram().BeginGroup(GroupOpt, "cached"), expected: `opt group "cached" is not ended`},
+		{name: "end without group", diagram: aDiagram().EndGroup(), expected: "event 3: group end without a group"},
+		{name: "branch without group", diagram: aDiagram().Else("x"), expected: "event 3: branch is not inside a group"},
+		{name: "branch of loop", diagram: aDiagram().BeginGroup(GroupLoop, "").Else("x").EndGroup(), expected: "event 4: loop group cannot have branches"},
+		{name: "unknown kind", diagram: aDiagram().BeginGroup("switch", "").EndGroup(), expected: `event 3: group has unknown kind "switch"`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.diagram.RenderMermaid()
+
+			assert.EqualError(t, err, test.expected)
+		})
+	}
+}
+
+func TestDiagram_MarshalJSON_RoundTripsGroups(t *testing.T) {
+	data, err := json.Marshal(aGroupedDiagram())
+	assert.Nil(t, err)
+
+	var diagram Diagram
+	err = json.Unmarshal(data, &diagram)
+
+	assert.Nil(t, err)
+	assert.Equal(t, aGroupedDiagram().Events, diagram.Events)
+}
+
+func TestDocument_ToHAR_SkipsGroups(t *testing.T) {
+	har, err := NewDocument().AddDiagram(NewDiagram().
+		BeginGroup(GroupAlt, "cache hit").
+		AddHttpRequest(aRequest()).
+		AddHttpResponse(aResponse()).
+		EndGroup()).ToHAR()
+
+	assert.Nil(t, err)
+	assert.NotContains(t, string(har), "_messages")
+}
+
+func aGroupedDiagram() *Diagram {
+	return NewDiagram().
+		AddMessageRequest(MessageRequest{Source: "cli", Target: "app", Header: "GET /cart"}).
+		BeginGroup(GroupPar, "fan out").
+		AddMessageRequest(MessageRequest{Source: "app", Target: "db", Header: "SELECT"}).
+		Else("").
+		BeginGroup(GroupLoop, "retry up to 3 times").
+		AddMessageRequest(MessageRequest{Source: "app", Target: "cache", Header: "GET cart"}).
+		EndGroup().
+		EndGroup().
+		AddMessageResponse(MessageResponse{Source: "app", Target: "cli", Header: "200"})
+}
@@ -0,0 +1,291 @@
+package sequence
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// harClient names the source participant of entries that do not belong to a page
+const harClient = "client"
+
+// HAR 1.2 types, see http://www.softwareishard.com/blog/har-12-spec/
+type (
+	har struct {
+		Log harLog `json:"log"`
+	}
+
+	harLog struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Pages   []harPage  `json:"pages,omitempty"`
+		Entries []harEntry `json:"entries"`
+		// Messages holds events that are not HTTP exchanges, using the custom field prefix allowed by the spec
+		Messages []harMessage `json:"_messages,omitempty"`
+	}
+
+	harMessage struct {
+		PageRef  string `json:"pageref,omitempty"`
+		Source   string `json:"source"`
+		Target   string `json:"target"`
+		Response bool   `json:"response"`
+		Label    string `json:"label"`
+		Header   string `json:"header"`
+		Body     string `json:"body,omitempty"`
+	}
+
+	harCreator struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+
+	harPage struct {
+		StartedDateTime time.Time      `json:"startedDateTime"`
+		ID              string         `json:"id"`
+		Title           string         `json:"title"`
+		PageTimings     harPageTimings `json:"pageTimings"`
+	}
+
+	harPageTimings struct{}
+
+	harEntry struct {
+		PageRef         string      `json:"pageref,omitempty"`
+		StartedDateTime time.Time   `json:"startedDateTime"`
+		Time            float64     `json:"time"`
+		Request         harRequest  `json:"request"`
+		Response        harResponse `json:"response"`
+		Cache           struct{}    `json:"cache"`
+		Timings         harTimings  `json:"timings"`
+	}
+
+	harRequest struct {
+		Method      string         `json:"method"`
+		URL         string         `json:"url"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []harCookie    `json:"cookies"`
+		Headers     []harNameValue `json:"headers"`
+		QueryString []harNameValue `json:"queryString"`
+		PostData    *harPostData   `json:"postData,omitempty"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int            `json:"bodySize"`
+	}
+
+	harResponse struct {
+		Status      int            `json:"status"`
+		StatusText  string         `json:"statusText"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []harCookie    `json:"cookies"`
+		Headers     []harNameValue `json:"headers"`
+		Content     harContent     `json:"content"`
+		RedirectURL string         `json:"redirectURL"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int            `json:"bodySize"`
+	}
+
+	harCookie struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	harNameValue struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	harPostData struct {
+		MimeType string         `json:"mimeType"`
+		Text     string         `json:"text"`
+		Params   []harNameValue `json:"params,omitempty"`
+	}
+
+	harContent struct {
+		Size     int    `json:"size"`
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text,omitempty"`
+		Encoding string `json:"encoding,omitempty"`
+	}
+
+	harTimings struct {
+		Send    float64 `json:"send"`
+		Wait    float64 `json:"wait"`
+		Receive float64 `json:"receive"`
+	}
+)
+
+// FromHAR reads an HTTP Archive and returns a diagram with a request and response for each entry. The
+// source participant is the page an entry belongs to and the target is the host the request was sent to
+func FromHAR(r io.Reader) (*Diagram, error) {
+	archive, err := readHAR(r)
+	if err != nil {
+		return nil, err
+	}
+
+	diagram := NewDiagram()
+	pages := harPageNames(archive.Log.Pages)
+	for _, entry := range sortedHAREntries(archive.Log.Entries) {
+		if err := addHAREntry(diagram, entry, pages); err != nil {
+			return nil, err
+		}
+	}
+	return diagram, nil
+}
+
+// DocumentFromHAR reads an HTTP Archive and returns a document with one diagram per page. Entries that
+// do not belong to a page are added to a final diagram
+func DocumentFromHAR(r io.Reader) (*Document, error) {
+	archive, err := readHAR(r)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := harPageNames(archive.Log.Pages)
+	diagrams := map[string]*Diagram{}
+	for _, page := range archive.Log.Pages {
+		diagrams[page.ID] = NewDiagram().AddTitle(pages[page.ID]).AddSubTitle(page.ID)
+	}
+
+	var other *Diagram
+	for _, entry := range sortedHAREntries(archive.Log.Entries) {
+		diagram, ok := diagrams[entry.PageRef]
+		if !ok {
+			if other == nil {
+				other = NewDiagram()
+			}
+			diagram = other
+		}
+		if err := addHAREntry(diagram, entry, pages); err != nil {
+			return nil, err
+		}
+	}
+
+	document := NewDocument()
+	for _, page := range archive.Log.Pages {
+		if len(diagrams[page.ID].Events) > 0 {
+			document.AddDiagram(diagrams[page.ID])
+		}
+	}
+	if other != nil {
+		document.AddDiagram(other)
+	}
+	return document, nil
+}
+
+func readHAR(r io.Reader) (har, error) {
+	var archive har
+	if err := json.NewDecoder(r).Decode(&archive); err != nil {
+		return har{}, fmt.Errorf("invalid HAR: %v", err)
+	}
+	return archive, nil
+}
+
+func harPageNames(pages []harPage) map[string]string {
+	names := map[string]string{}
+	for _, page := range pages {
+		names[page.ID] = page.Title
+		if page.Title == "" {
+			names[page.ID] = page.ID
+		}
+	}
+	return names
+}
+
+func sortedHAREntries(entries []harEntry) []harEntry {
+	sorted := make([]harEntry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].StartedDateTime.Before(sorted[j].StartedDateTime)
+	})
+	return sorted
+}
+
+func addHAREntry(diagram *Diagram, entry harEntry, pages map[string]string) error {
+	req, err := entry.Request.toHTTP()
+	if err != nil {
+		return err
+	}
+	res, err := entry.Response.toHTTP(req)
+	if err != nil {
+		return err
+	}
+
+	source, ok := pages[entry.PageRef]
+	if !ok {
+		source = harClient
+	}
+	start := entry.StartedDateTime
+	var end time.Time
+	if !start.IsZero() && entry.Time > 0 {
+		end = start.Add(time.Duration(entry.Time * float64(time.Millisecond)))
+	}
+	diagram.AddHttpRequest(HttpRequest{Source: source, Target: req.URL.Host, Value: req, Start: start})
+	diagram.AddHttpResponse(HttpResponse{Source: req.URL.Host, Target: source, Value: res, Start: start, End: end})
+	return nil
+}
+
+func (r harRequest) toHTTP() (*http.Request, error) {
+	var body io.Reader
+	if r.PostData != nil {
+		body = strings.NewReader(r.PostData.Text)
+	}
+	req, err := http.NewRequest(r.Method, r.URL, body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid HAR request: %v", err)
+	}
+	req.Header = harHeaders(r.Headers)
+	if r.PostData != nil && req.Header.Get("Content-Type") == "" && r.PostData.MimeType != "" {
+		req.Header.Set("Content-Type", r.PostData.MimeType)
+	}
+	return req, nil
+}
+
+func (r harResponse) toHTTP(req *http.Request) (*http.Response, error) {
+	body := []byte(r.Content.Text)
+	if r.Content.Encoding == "base64" {
+		decoded, err := base64.StdEncoding.DecodeString(r.Content.Text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid HAR response content: %v", err)
+		}
+		body = decoded
+	}
+
+	header := harHeaders(r.Headers)
+	if header.Get("Content-Type") == "" && r.Content.MimeType != "" {
+		header.Set("Content-Type", r.Content.MimeType)
+	}
+	// content.text holds the decoded body, so the headers describing the body as sent no longer apply
+	header.Del("Content-Encoding")
+	header.Del("Content-Length")
+	major, minor, ok := http.ParseHTTPVersion(r.HTTPVersion)
+	if !ok {
+		major, minor = 1, 1
+	}
+	return &http.Response{
+		Status:        strings.TrimSpace(fmt.Sprintf("%d %s", r.Status, r.StatusText)),
+		StatusCode:    r.Status,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		ProtoMajor:    major,
+		ProtoMinor:    minor,
+		Request:       req,
+	}, nil
+}
+
+// harHeaders converts HAR headers, skipping HTTP/2 pseudo headers such as :authority
+func harHeaders(headers []harNameValue) http.Header {
+	header := http.Header{}
+	for _, h := range headers {
+		if strings.HasPrefix(h.Name, ":") {
+			continue
+		}
+		header.Add(h.Name, h.Value)
+	}
+	return header
+}
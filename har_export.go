@@ -0,0 +1,232 @@
+package sequence
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+const harCreatorName = "sequence-diagrams"
+
+// ToHAR writes the HTTP exchanges recorded on the diagram as HAR 1.2 JSON. Message and custom events
+// have no HAR equivalent and are written to the custom _messages field of the log
+func (r *Diagram) ToHAR() ([]byte, error) {
+	archive := newHAR()
+	if err := r.addToHAR(&archive.Log, ""); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(archive, "", "  ")
+}
+
+// ToHAR writes every diagram in the document as HAR 1.2 JSON with one page per diagram
+func (r *Document) ToHAR() ([]byte, error) {
+	archive := newHAR()
+	for i, d := range r.diagrams() {
+		page := harPage{ID: fmt.Sprintf("page_%d", i+1), Title: d.Title}
+		archive.Log.Pages = append(archive.Log.Pages, page)
+		if err := d.addToHAR(&archive.Log, page.ID); err != nil {
+			return nil, err
+		}
+	}
+	return json.MarshalIndent(archive, "", "  ")
+}
+
+func newHAR() har {
+	return har{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: harCreatorName},
+		Entries: []harEntry{},
+	}}
+}
+
+// addToHAR pairs each HttpResponse with the most recent unanswered HttpRequest between the same
+// participants. Requests that never received a response are written with status 0
+func (r *Diagram) addToHAR(log *harLog, pageRef string) error {
+	type pending struct {
+		event HttpRequest
+		entry int
+	}
+	var unanswered []pending
+
+	events, err := r.events()
+	if err != nil {
+		return err
+	}
+	for i, event := range events {
+		// annotations such as notes and groups are not traffic
+		if isAnnotation(event) {
+			continue
+		}
+		switch v := event.(type) {
+		case nil:
+			return fmt.Errorf("event %d is nil", i+1)
+		case HttpRequest:
+			if v.Value == nil {
+				return fmt.Errorf("event %d: http request event has no request", i+1)
+			}
+			request, err := newHARRequest(v.Value)
+			if err != nil {
+				return err
+			}
+			log.Entries = append(log.Entries, harEntry{PageRef: pageRef, StartedDateTime: v.Start, Request: request, Response: harResponse{
+				HTTPVersion: request.HTTPVersion,
+				Cookies:     []harCookie{},
+				Headers:     []harNameValue{},
+				HeadersSize: -1,
+				BodySize:    -1,
+			}})
+			unanswered = append(unanswered, pending{event: v, entry: len(log.Entries) - 1})
+		case HttpResponse:
+			if v.Value == nil {
+				return fmt.Errorf("event %d: http response event has no response", i+1)
+			}
+			response, err := newHARResponse(v.Value)
+			if err != nil {
+				return err
+			}
+			matched := false
+			for j := len(unanswered) - 1; j >= 0; j-- {
+				if unanswered[j].event.Source == v.Target && unanswered[j].event.Target == v.Source {
+					entry := &log.Entries[unanswered[j].entry]
+					entry.Response = response
+					if duration, ok := eventDuration(v); ok {
+						entry.Time = float64(duration) / float64(time.Millisecond)
+						entry.Timings.Wait = entry.Time
+					}
+					if entry.StartedDateTime.IsZero() {
+						entry.StartedDateTime = v.Start
+					}
+					unanswered = append(unanswered[:j], unanswered[j+1:]...)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return fmt.Errorf("event %d: http response from %s to %s does not answer a request", i+1, v.Source, v.Target)
+			}
+		default:
+			entry, err := event.LogEntry()
+			if err != nil {
+				return err
+			}
+			log.Messages = append(log.Messages, harMessage{
+				PageRef:  pageRef,
+				Source:   event.From(),
+				Target:   event.To(),
+				Response: event.IsResponse(),
+				Label:    event.Label(),
+				Header:   entry.Header,
+				Body:     entry.Body,
+			})
+		}
+	}
+	return nil
+}
+
+func newHARRequest(req *http.Request) (harRequest, error) {
+	var body []byte
+	var err error
+	body, req.Body, err = readBody(req.Body)
+	if err != nil {
+		return harRequest{}, err
+	}
+
+	request := harRequest{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: harHTTPVersion(req.Proto, req.ProtoMajor, req.ProtoMinor),
+		Cookies:     []harCookie{},
+		Headers:     newHARHeaders(req.Header),
+		QueryString: newHARQueryString(req.URL.Query()),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	for _, c := range req.Cookies() {
+		request.Cookies = append(request.Cookies, harCookie{Name: c.Name, Value: c.Value})
+	}
+	if len(body) > 0 {
+		contentType := req.Header.Get("Content-Type")
+		request.PostData = &harPostData{MimeType: contentType, Text: string(body)}
+		if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType == "application/x-www-form-urlencoded" {
+			if values, err := url.ParseQuery(string(body)); err == nil {
+				request.PostData.Params = newHARQueryString(values)
+			}
+		}
+	}
+	return request, nil
+}
+
+func newHARResponse(res *http.Response) (harResponse, error) {
+	var body []byte
+	var err error
+	body, res.Body, err = readBody(res.Body)
+	if err != nil {
+		return harResponse{}, err
+	}
+
+	// HAR content holds the decoded body, while the body size is the size as sent
+	content := body
+	if decoded, err := decodeContent(body, res.Header.Get("Content-Encoding")); err == nil {
+		content = decoded
+	}
+	response := harResponse{
+		Status:      res.StatusCode,
+		StatusText:  http.StatusText(res.StatusCode),
+		HTTPVersion: harHTTPVersion(res.Proto, res.ProtoMajor, res.ProtoMinor),
+		Cookies:     []harCookie{},
+		Headers:     newHARHeaders(res.Header),
+		Content: harContent{
+			Size:     len(content),
+			MimeType: res.Header.Get("Content-Type"),
+		},
+		RedirectURL: res.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	for _, c := range res.Cookies() {
+		response.Cookies = append(response.Cookies, harCookie{Name: c.Name, Value: c.Value})
+	}
+	if utf8.Valid(content) {
+		response.Content.Text = string(content)
+	} else {
+		response.Content.Text = base64.StdEncoding.EncodeToString(content)
+		response.Content.Encoding = "base64"
+	}
+	return response, nil
+}
+
+func harHTTPVersion(proto string, major, minor int) string {
+	if proto != "" {
+		return proto
+	}
+	if major == 0 {
+		return "HTTP/1.1"
+	}
+	return fmt.Sprintf("HTTP/%d.%d", major, minor)
+}
+
+// newHARHeaders returns the headers sorted by name so exports are deterministic
+func newHARHeaders(header http.Header) []harNameValue {
+	return newHARQueryString(url.Values(header))
+}
+
+func newHARQueryString(values url.Values) []harNameValue {
+	pairs := []harNameValue{}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range values[name] {
+			pairs = append(pairs, harNameValue{Name: name, Value: value})
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,152 @@
+package sequence
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDiagram_ToHAR(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com/posts?b=2&a=1", strings.NewReader("title=go&draft=1"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	res := &http.Response{
+		StatusCode: http.StatusCreated,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(`{"id":1}`)),
+	}
+	diagram := NewDiagram().
+		AddHttpRequest(HttpRequest{Source: "app", Target: "example.com", Value: req}).
+		AddHttpResponse(HttpResponse{Source: "example.com", Target: "app", Value: res})
+
+	data, err := diagram.ToHAR()
+
+	assert.Nil(t, err)
+	var archive har
+	assert.Nil(t, json.Unmarshal(data, &archive))
+	assert.Equal(t, "1.2", archive.Log.Version)
+	assert.Len(t, archive.Log.Entries, 1)
+	entry := archive.Log.Entries[0]
+	assert.Equal(t, "POST", entry.Request.Method)
+	assert.Equal(t, "http://example.com/posts?b=2&a=1", entry.Request.URL)
+	assert.Equal(t, []harNameValue{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}}, entry.Request.QueryString)
+	assert.Equal(t, []harCookie{{Name: "session", Value: "abc"}}, entry.Request.Cookies)
+	assert.Equal(t, "title=go&draft=1", entry.Request.PostData.Text)
+	assert.Equal(t, []harNameValue{{Name: "draft", Value: "1"}, {Name: "title", Value: "go"}}, entry.Request.PostData.Params)
+	assert.Equal(t, http.StatusCreated, entry.Response.Status)
+	assert.Equal(t, "Created", entry.Response.StatusText)
+	assert.Equal(t, `{"id":1}`, entry.Response.Content.Text)
+	assert.Equal(t, "application/json", entry.Response.Content.MimeType)
+}
+
+func TestDiagram_ToHAR_PairsResponsesWithRequests(t *testing.T) {
+	diagram := NewDiagram().
+		AddHttpRequest(HttpRequest{Source: "consumer", Target: "app", Value: aRequest().Value}).
+		AddHttpRequest(HttpRequest{Source: "app", Target: "db", Value: aRequest().Value}).
+		AddHttpResponse(HttpResponse{Source: "db", Target: "app", Value: &http.Response{StatusCode: http.StatusOK}}).
+		AddHttpResponse(HttpResponse{Source: "app", Target: "consumer", Value: &http.Response{StatusCode: http.StatusAccepted}})
+
+	data, err := diagram.ToHAR()
+
+	assert.Nil(t, err)
+	var archive har
+	json.Unmarshal(data, &archive)
+	assert.Equal(t, http.StatusAccepted, archive.Log.Entries[0].Response.Status)
+	assert.Equal(t, http.StatusOK, archive.Log.Entries[1].Response.Status)
+}
+
+func TestDiagram_ToHAR_WritesMessagesToCustomField(t *testing.T) {
+	diagram := NewDiagram().
+		AddMessageRequest(MessageRequest{Source: "app", Target: "kafka", Header: "publish", Body: "B"}).
+		AddMessageResponse(MessageResponse{Source: "kafka", Target: "app", Header: "ack"})
+
+	data, err := diagram.ToHAR()
+
+	assert.Nil(t, err)
+	var archive har
+	json.Unmarshal(data, &archive)
+	assert.Empty(t, archive.Log.Entries)
+	assert.Equal(t, []harMessage{
+		{Source: "app", Target: "kafka", Label: "publish", Header: "publish", Body: "B"},
+		{Source: "kafka", Target: "app", Response: true, Label: "ack", Header: "ack"},
+	}, archive.Log.Messages)
+}
+
+func TestDiagram_ToHAR_EncodesBinaryContent(t *testing.T) {
+	res := &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader("\xff\xfe"))}
+	diagram := NewDiagram().AddHttpRequest(aRequest()).AddHttpResponse(HttpResponse{Value: res})
+
+	data, _ := diagram.ToHAR()
+
+	var archive har
+	json.Unmarshal(data, &archive)
+	assert.Equal(t, "base64", archive.Log.Entries[0].Response.Content.Encoding)
+	assert.Equal(t, "//4=", archive.Log.Entries[0].Response.Content.Text)
+}
+
+func TestDiagram_ToHAR_WritesDecodedContent(t *testing.T) {
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	gz.Write([]byte(`{"id":1}`))
+	gz.Close()
+	res := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Encoding": []string{"gzip"}},
+		Body:       ioutil.NopCloser(bytes.NewReader(gzipped.Bytes())),
+	}
+	diagram := NewDiagram().AddHttpRequest(aRequest()).AddHttpResponse(HttpResponse{Value: res})
+
+	data, _ := diagram.ToHAR()
+
+	var archive har
+	json.Unmarshal(data, &archive)
+	response := archive.Log.Entries[0].Response
+	assert.Equal(t, `{"id":1}`, response.Content.Text)
+	assert.Equal(t, 8, response.Content.Size)
+	assert.Equal(t, gzipped.Len(), response.BodySize)
+}
+
+func TestDiagram_ToHAR_ErrorIfResponseDoesNotAnswerRequest(t *testing.T) {
+	_, err := NewDiagram().AddHttpResponse(HttpResponse{Source: "a", Target: "b", Value: &http.Response{}}).ToHAR()
+
+	assert.EqualError(t, err, "event 1: http response from a to b does not answer a request")
+}
+
+func TestDocument_ToHAR_RoundTrips(t *testing.T) {
+	document := NewDocument().
+		AddDiagram(aDiagram().AddTitle("first")).
+		AddDiagram(aDiagram().AddTitle("second"))
+
+	data, err := document.ToHAR()
+	assert.Nil(t, err)
+	imported, err := DocumentFromHAR(bytes.NewReader(data))
+
+	assert.Nil(t, err)
+	assert.Len(t, imported.Diagrams, 2)
+	assert.Equal(t, "second", imported.Diagrams[1].Title)
+	assert.Equal(t, "GET http://example.com/abcdef", imported.Diagrams[1].Events[0].Label())
+	assert.Equal(t, "204", imported.Diagrams[1].Events[1].Label())
+}
+
+func TestDiagram_ToHAR_WritesTiming(t *testing.T) {
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	req := aRequest()
+	req.Start = start
+	res := aResponse()
+	res.Start, res.End = start, start.Add(1500*time.Microsecond)
+
+	data, err := NewDiagram().AddHttpRequest(req).AddHttpResponse(res).ToHAR()
+
+	assert.Nil(t, err)
+	var archive har
+	assert.Nil(t, json.Unmarshal(data, &archive))
+	assert.Equal(t, start, archive.Log.Entries[0].StartedDateTime)
+	assert.Equal(t, 1.5, archive.Log.Entries[0].Time)
+	assert.Equal(t, 1.5, archive.Log.Entries[0].Timings.Wait)
+}
@@ -0,0 +1,138 @@
+package sequence
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+const harFixture = `{
+  "log": {
+    "version": "1.2",
+    "creator": {"name": "devtools", "version": "1"},
+    "pages": [
+      {"startedDateTime": "2018-12-16T10:00:00.000Z", "id": "page_1", "title": "Posts", "pageTimings": {}},
+      {"startedDateTime": "2018-12-16T10:01:00.000Z", "id": "page_2", "title": "", "pageTimings": {}}
+    ],
+    "entries": [
+      {
+        "pageref": "page_1",
+        "startedDateTime": "2018-12-16T10:00:02.000Z",
+        "time": 12,
+        "request": {
+          "method": "POST", "url": "https://api.example.com/posts?draft=true", "httpVersion": "HTTP/1.1",
+          "headers": [{"name": ":authority", "value": "api.example.com"}, {"name": "Accept", "value": "application/json"}],
+          "queryString": [{"name": "draft", "value": "true"}],
+          "postData": {"mimeType": "text/plain", "text": "hello"},
+          "headersSize": -1, "bodySize": 5
+        },
+        "response": {
+          "status": 201, "statusText": "Created", "httpVersion": "HTTP/1.1",
+          "headers": [{"name": "Content-Type", "value": "text/plain"}],
+          "content": {"size": 7, "mimeType": "text/plain", "text": "Y3JlYXRlZA==", "encoding": "base64"},
+          "redirectURL": "", "headersSize": -1, "bodySize": 7
+        },
+        "cache": {}, "timings": {"send": 1, "wait": 10, "receive": 1}
+      },
+      {
+        "pageref": "page_1",
+        "startedDateTime": "2018-12-16T10:00:01.000Z",
+        "time": 5,
+        "request": {"method": "GET", "url": "https://cdn.example.com/app.js", "httpVersion": "h2", "headers": [], "queryString": [], "headersSize": -1, "bodySize": 0},
+        "response": {"status": 200, "statusText": "OK", "httpVersion": "h2", "headers": [], "content": {"size": 0, "mimeType": "text/javascript"}, "redirectURL": "", "headersSize": -1, "bodySize": 0},
+        "cache": {}, "timings": {"send": 1, "wait": 3, "receive": 1}
+      },
+      {
+        "pageref": "page_2",
+        "startedDateTime": "2018-12-16T10:01:01.000Z",
+        "time": 5,
+        "request": {"method": "GET", "url": "https://api.example.com/users", "httpVersion": "HTTP/1.1", "headers": [], "queryString": [], "headersSize": -1, "bodySize": 0},
+        "response": {"status": 404, "statusText": "Not Found", "httpVersion": "HTTP/1.1", "headers": [], "content": {"size": 0, "mimeType": ""}, "redirectURL": "", "headersSize": -1, "bodySize": 0},
+        "cache": {}, "timings": {"send": 1, "wait": 3, "receive": 1}
+      },
+      {
+        "startedDateTime": "2018-12-16T10:02:00.000Z",
+        "time": 5,
+        "request": {"method": "GET", "url": "https://api.example.com/health", "httpVersion": "HTTP/1.1", "headers": [], "queryString": [], "headersSize": -1, "bodySize": 0},
+        "response": {"status": 200, "statusText": "OK", "httpVersion": "HTTP/1.1", "headers": [], "content": {"size": 0, "mimeType": ""}, "redirectURL": "", "headersSize": -1, "bodySize": 0},
+        "cache": {}, "timings": {"send": 1, "wait": 3, "receive": 1}
+      }
+    ]
+  }
+}`
+
+func TestFromHAR(t *testing.T) {
+	diagram, err := FromHAR(strings.NewReader(harFixture))
+
+	assert.Nil(t, err)
+	assert.Len(t, diagram.Events, 8)
+	assert.Equal(t, "Posts", diagram.Events[0].From())
+	assert.Equal(t, "cdn.example.com", diagram.Events[0].To())
+	assert.Equal(t, "POST https://api.example.com/posts?draft=true", diagram.Events[2].Label())
+	assert.Equal(t, "page_2", diagram.Events[4].From())
+	assert.Equal(t, "client", diagram.Events[6].From())
+}
+
+func TestFromHAR_ConvertsRequestsAndResponses(t *testing.T) {
+	diagram, _ := FromHAR(strings.NewReader(harFixture))
+
+	model, err := diagram.BuildModel()
+
+	assert.Nil(t, err)
+	assert.Contains(t, model.LogEntries[2].Header, "POST /posts?draft=true HTTP/1.1")
+	assert.Contains(t, model.LogEntries[2].Header, "Accept: application/json")
+	assert.NotContains(t, model.LogEntries[2].Header, ":authority")
+	assert.Equal(t, "hello", model.LogEntries[2].Body)
+	assert.Contains(t, model.LogEntries[3].Header, "HTTP/1.1 201 Created")
+	assert.Equal(t, "created", model.LogEntries[3].Body)
+	assert.Contains(t, model.LogEntries[1].Header, "Content-Type: text/javascript")
+}
+
+func TestDocumentFromHAR_GroupsEntriesByPage(t *testing.T) {
+	document, err := DocumentFromHAR(strings.NewReader(harFixture))
+
+	assert.Nil(t, err)
+	assert.Len(t, document.Diagrams, 3)
+	assert.Equal(t, "Posts", document.Diagrams[0].Title)
+	assert.Len(t, document.Diagrams[0].Events, 4)
+	assert.Equal(t, "page_2", document.Diagrams[1].Title)
+	assert.Len(t, document.Diagrams[2].Events, 2)
+	assert.Equal(t, "client", document.Diagrams[2].Events[0].From())
+}
+
+func TestFromHAR_ErrorIfInvalid(t *testing.T) {
+	_, err := FromHAR(strings.NewReader("{"))
+
+	assert.EqualError(t, err, "invalid HAR: unexpected EOF")
+}
+
+func TestFromHAR_RecordsEntryTiming(t *testing.T) {
+	diagram, _ := FromHAR(strings.NewReader(harFixture))
+
+	duration, ok := eventDuration(diagram.Events[3])
+
+	assert.True(t, ok)
+	assert.Equal(t, 12*time.Millisecond, duration)
+	assert.Equal(t, time.Date(2018, 12, 16, 10, 0, 2, 0, time.UTC), diagram.Events[2].(HttpRequest).Start)
+}
+
+func TestFromHAR_DropsContentEncodingOfDecodedContent(t *testing.T) {
+	fixture := `{"log": {"version": "1.2", "entries": [{
+		"startedDateTime": "2018-12-16T10:00:00.000Z",
+		"request": {"method": "GET", "url": "https://api.example.com/posts", "httpVersion": "HTTP/1.1", "headers": []},
+		"response": {
+			"status": 200, "statusText": "OK", "httpVersion": "HTTP/1.1",
+			"headers": [{"name": "Content-Encoding", "value": "gzip"}, {"name": "Content-Length", "value": "31"}],
+			"content": {"size": 11, "mimeType": "application/json", "text": "{\"id\": 1}"}
+		}
+	}]}}`
+	diagram, err := FromHAR(strings.NewReader(fixture))
+	assert.Nil(t, err)
+
+	model, err := diagram.BuildModel()
+
+	assert.Nil(t, err)
+	assert.NotContains(t, model.LogEntries[1].Header, "Content-Encoding")
+	assert.Equal(t, "{\n    \"id\": 1\n}", model.LogEntries[1].Body)
+}
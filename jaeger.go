@@ -0,0 +1,98 @@
+package sequence
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Jaeger UI JSON types, as returned by /api/traces and the UI's download button
+type (
+	jaegerExport struct {
+		Data []jaegerTrace `json:"data"`
+	}
+
+	jaegerTrace struct {
+		TraceID   string                   `json:"traceID"`
+		Spans     []jaegerSpan             `json:"spans"`
+		Processes map[string]jaegerProcess `json:"processes"`
+	}
+
+	jaegerSpan struct {
+		TraceID       string            `json:"traceID"`
+		SpanID        string            `json:"spanID"`
+		OperationName string            `json:"operationName"`
+		References    []jaegerReference `json:"references"`
+		StartTime     int64             `json:"startTime"`
+		Duration      int64             `json:"duration"`
+		Tags          []jaegerTag       `json:"tags"`
+		ProcessID     string            `json:"processID"`
+	}
+
+	jaegerReference struct {
+		RefType string `json:"refType"`
+		TraceID string `json:"traceID"`
+		SpanID  string `json:"spanID"`
+	}
+
+	jaegerTag struct {
+		Key   string          `json:"key"`
+		Value json.RawMessage `json:"value"`
+	}
+
+	jaegerProcess struct {
+		ServiceName string `json:"serviceName"`
+	}
+)
+
+// DocumentFromJaeger reads a Jaeger UI JSON export and returns a document with one diagram per trace
+func DocumentFromJaeger(r io.Reader) (*Document, error) {
+	var export jaegerExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("invalid Jaeger trace: %v", err)
+	}
+
+	var spans []span
+	for _, trace := range export.Data {
+		for _, s := range trace.Spans {
+			spans = append(spans, s.toSpan(trace.Processes[s.ProcessID].ServiceName))
+		}
+	}
+	if len(spans) == 0 {
+		return nil, fmt.Errorf("Jaeger trace has no spans")
+	}
+	return documentFromSpans(spans), nil
+}
+
+func (r jaegerSpan) toSpan(service string) span {
+	attributes := map[string]string{}
+	for _, tag := range r.Tags {
+		var s string
+		if err := json.Unmarshal(tag.Value, &s); err == nil {
+			attributes[tag.Key] = s
+		} else {
+			attributes[tag.Key] = string(tag.Value)
+		}
+	}
+
+	var parentID string
+	for _, ref := range r.References {
+		if ref.RefType == "CHILD_OF" || parentID == "" {
+			parentID = ref.SpanID
+		}
+	}
+
+	start := time.Unix(0, r.StartTime*int64(time.Microsecond)).UTC()
+	return span{
+		traceID:    r.TraceID,
+		spanID:     r.SpanID,
+		parentID:   parentID,
+		service:    service,
+		name:       r.OperationName,
+		kind:       spanKindFromName(attributes["span.kind"]),
+		start:      start,
+		end:        start.Add(time.Duration(r.Duration) * time.Microsecond),
+		attributes: attributes,
+	}
+}
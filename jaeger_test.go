@@ -0,0 +1,79 @@
+package sequence
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+const jaegerFixture = `{
+  "data": [
+    {
+      "traceID": "trace1",
+      "spans": [
+        {
+          "traceID": "trace1", "spanID": "a", "operationName": "GET /orders", "references": [],
+          "startTime": 1544712660000000, "duration": 1000000, "processID": "p1",
+          "tags": [{"key": "span.kind", "type": "string", "value": "server"}, {"key": "http.status_code", "type": "int64", "value": 200}]
+        },
+        {
+          "traceID": "trace1", "spanID": "b", "operationName": "GET", "references": [{"refType": "CHILD_OF", "traceID": "trace1", "spanID": "a"}],
+          "startTime": 1544712660100000, "duration": 500000, "processID": "p1",
+          "tags": [{"key": "span.kind", "type": "string", "value": "client"}, {"key": "http.method", "type": "string", "value": "GET"}, {"key": "http.url", "type": "string", "value": "http://stock/items"}]
+        },
+        {
+          "traceID": "trace1", "spanID": "c", "operationName": "GET /items", "references": [{"refType": "CHILD_OF", "traceID": "trace1", "spanID": "b"}],
+          "startTime": 1544712660200000, "duration": 300000, "processID": "p2",
+          "tags": [{"key": "span.kind", "type": "string", "value": "server"}, {"key": "http.status_code", "type": "int64", "value": 503}]
+        }
+      ],
+      "processes": {"p1": {"serviceName": "orders", "tags": []}, "p2": {"serviceName": "stock", "tags": []}}
+    },
+    {
+      "traceID": "trace2",
+      "spans": [
+        {
+          "traceID": "trace2", "spanID": "d", "operationName": "GET /health", "references": [],
+          "startTime": 1544712670000000, "duration": 1000, "processID": "p1",
+          "tags": [{"key": "span.kind", "type": "string", "value": "server"}]
+        }
+      ],
+      "processes": {"p1": {"serviceName": "orders", "tags": []}}
+    }
+  ]
+}`
+
+func TestDocumentFromJaeger(t *testing.T) {
+	document, err := DocumentFromJaeger(strings.NewReader(jaegerFixture))
+
+	assert.Nil(t, err)
+	assert.Len(t, document.Diagrams, 2)
+	assert.Equal(t, "GET /orders", document.Diagrams[0].Title)
+	assert.Equal(t, "trace1", document.Diagrams[0].SubTitle)
+	var rows []string
+	for _, e := range document.Diagrams[0].Events {
+		rows = append(rows, e.From()+" -> "+e.To()+": "+e.Label())
+	}
+	assert.Equal(t, []string{
+		"client -> orders: GET /orders",
+		"orders -> stock: GET http://stock/items",
+		"stock -> orders: 503",
+		"orders -> client: 200",
+	}, rows)
+	assert.Len(t, document.Diagrams[1].Events, 2)
+}
+
+func TestDocumentFromJaeger_RendersHTML(t *testing.T) {
+	document, _ := DocumentFromJaeger(strings.NewReader(jaegerFixture))
+
+	html, err := document.RenderHTML()
+
+	assert.Nil(t, err)
+	assert.Contains(t, html, "<h1>GET /orders</h1>")
+}
+
+func TestDocumentFromJaeger_ErrorIfNoSpans(t *testing.T) {
+	_, err := DocumentFromJaeger(strings.NewReader(`{"data": []}`))
+
+	assert.EqualError(t, err, "Jaeger trace has no spans")
+}
@@ -0,0 +1,390 @@
+package sequence
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// jsonVersion is the version of the JSON recording format written by Document.MarshalJSON. Readers
+// reject recordings written by a newer version
+const jsonVersion = 1
+
+// Event type names used as the discriminator of events in the JSON recording format
+const (
+	eventTypeHttpRequest     = "http_request"
+	eventTypeHttpResponse    = "http_response"
+	eventTypeMessageRequest  = "message_request"
+	eventTypeMessageResponse = "message_response"
+	eventTypeSpanRequest     = "span_request"
+	eventTypeSpanResponse    = "span_response"
+	eventTypeNote            = "note"
+	eventTypeDivider         = "divider"
+	eventTypeGroupStart      = "group_start"
+	eventTypeGroupBranch     = "group_branch"
+	eventTypeGroupEnd        = "group_end"
+)
+
+type (
+	documentJSON struct {
+		Version     int             `json:"version"`
+		Title       string          `json:"title,omitempty"`
+		Description string          `json:"description,omitempty"`
+		Meta        json.RawMessage `json:"meta,omitempty"`
+		Diagrams    []*Diagram      `json:"diagrams"`
+	}
+
+	diagramJSON struct {
+		Title        string        `json:"title,omitempty"`
+		SubTitle     string        `json:"subTitle,omitempty"`
+		Participants []Participant `json:"participants,omitempty"`
+		Events       []eventJSON   `json:"events"`
+	}
+
+	eventJSON struct {
+		Type         string            `json:"type"`
+		Source       string            `json:"source,omitempty"`
+		Target       string            `json:"target,omitempty"`
+		Method       string            `json:"method,omitempty"`
+		URL          string            `json:"url,omitempty"`
+		Host         string            `json:"host,omitempty"`
+		Proto        string            `json:"proto,omitempty"`
+		Status       int               `json:"status,omitempty"`
+		Header       string            `json:"header,omitempty"`
+		Headers      http.Header       `json:"headers,omitempty"`
+		Body         string            `json:"body,omitempty"`
+		BodyBase64   string            `json:"bodyBase64,omitempty"`
+		Name         string            `json:"name,omitempty"`
+		TraceID      string            `json:"traceId,omitempty"`
+		SpanID       string            `json:"spanId,omitempty"`
+		Start        *time.Time        `json:"start,omitempty"`
+		End          *time.Time        `json:"end,omitempty"`
+		Attributes   map[string]string `json:"attributes,omitempty"`
+		Kind         string            `json:"kind,omitempty"`
+		Position     string            `json:"position,omitempty"`
+		Participants []string          `json:"participants,omitempty"`
+		Text         string            `json:"text,omitempty"`
+		Data         json.RawMessage   `json:"data,omitempty"`
+	}
+)
+
+var eventTypes = struct {
+	sync.RWMutex
+	byName map[string]reflect.Type
+	byType map[reflect.Type]string
+}{byName: map[string]reflect.Type{}, byType: map[reflect.Type]string{}}
+
+// RegisterEventType makes a custom event type serializable. The event is written to the "data" field
+// of the JSON recording using encoding/json, and read back as the same type. Like gob.Register it
+// panics if the name or type is already registered with a different counterpart
+func RegisterEventType(name string, event Event) {
+	t := reflect.TypeOf(event)
+	eventTypes.Lock()
+	defer eventTypes.Unlock()
+
+	if existing, ok := eventTypes.byName[name]; ok && existing != t {
+		panic(fmt.Sprintf("sequence: registering duplicate event type name %q", name))
+	}
+	if existing, ok := eventTypes.byType[t]; ok && existing != name {
+		panic(fmt.Sprintf("sequence: registering duplicate name for event type %s", t))
+	}
+	eventTypes.byName[name] = t
+	eventTypes.byType[t] = name
+}
+
+// MarshalJSON writes the document in the versioned JSON recording format. HTTP bodies are captured,
+// so a recording can be rendered later or on another machine
+func (r *Document) MarshalJSON() ([]byte, error) {
+	document := documentJSON{
+		Version:     jsonVersion,
+		Title:       r.Title,
+		Description: r.Description,
+		Diagrams:    r.diagrams(),
+	}
+	if r.MetaJSON != "" {
+		if json.Valid([]byte(r.MetaJSON)) {
+			document.Meta = json.RawMessage(r.MetaJSON)
+		} else {
+			meta, _ := json.Marshal(string(r.MetaJSON))
+			document.Meta = meta
+		}
+	}
+	if document.Diagrams == nil {
+		document.Diagrams = []*Diagram{}
+	}
+	return json.Marshal(document)
+}
+
+func (r *Document) UnmarshalJSON(data []byte) error {
+	var document documentJSON
+	if err := json.Unmarshal(data, &document); err != nil {
+		return err
+	}
+	if document.Version < 1 || document.Version > jsonVersion {
+		return fmt.Errorf("unsupported document version %d", document.Version)
+	}
+
+	r.Title = document.Title
+	r.Description = document.Description
+	r.MetaJSON = template.JS(document.Meta)
+	r.Diagrams = document.Diagrams
+	return nil
+}
+
+func (r *Diagram) MarshalJSON() ([]byte, error) {
+	events, err := r.events()
+	if err != nil {
+		return nil, err
+	}
+	diagram := diagramJSON{Title: r.Title, SubTitle: r.SubTitle, Participants: r.participants(), Events: []eventJSON{}}
+	for i, event := range events {
+		encoded, err := marshalEvent(event)
+		if err != nil {
+			return nil, fmt.Errorf("event %d: %v", i+1, err)
+		}
+		diagram.Events = append(diagram.Events, encoded)
+	}
+	return json.Marshal(diagram)
+}
+
+func (r *Diagram) UnmarshalJSON(data []byte) error {
+	var diagram diagramJSON
+	if err := json.Unmarshal(data, &diagram); err != nil {
+		return err
+	}
+
+	r.Title = diagram.Title
+	r.SubTitle = diagram.SubTitle
+	r.Participants = diagram.Participants
+	r.Events = nil
+	for i, encoded := range diagram.Events {
+		event, err := encoded.toEvent()
+		if err != nil {
+			return fmt.Errorf("event %d: %v", i+1, err)
+		}
+		r.Events = append(r.Events, event)
+	}
+	return nil
+}
+
+func marshalEvent(event Event) (eventJSON, error) {
+	switch v := event.(type) {
+	case nil:
+		return eventJSON{}, fmt.Errorf("event is nil")
+	case HttpRequest:
+		if v.Value == nil {
+			return eventJSON{}, fmt.Errorf("http request event has no request")
+		}
+		body, rest, err := readBody(v.Value.Body)
+		if err != nil {
+			return eventJSON{}, err
+		}
+		v.Value.Body = rest
+		encoded := eventJSON{
+			Type:    eventTypeHttpRequest,
+			Source:  v.Source,
+			Target:  v.Target,
+			Method:  v.Value.Method,
+			URL:     v.Value.URL.String(),
+			Proto:   protoString(v.Value.Proto, v.Value.ProtoMajor, v.Value.ProtoMinor),
+			Headers: v.Value.Header,
+			Start:   timePointer(v.Start),
+			End:     timePointer(v.End),
+		}
+		if v.Value.Host != v.Value.URL.Host {
+			encoded.Host = v.Value.Host
+		}
+		encoded.setBody(body)
+		return encoded, nil
+	case HttpResponse:
+		if v.Value == nil {
+			return eventJSON{}, fmt.Errorf("http response event has no response")
+		}
+		body, rest, err := readBody(v.Value.Body)
+		if err != nil {
+			return eventJSON{}, err
+		}
+		v.Value.Body = rest
+		encoded := eventJSON{
+			Type:    eventTypeHttpResponse,
+			Source:  v.Source,
+			Target:  v.Target,
+			Status:  v.Value.StatusCode,
+			Proto:   protoString(v.Value.Proto, v.Value.ProtoMajor, v.Value.ProtoMinor),
+			Headers: v.Value.Header,
+			Start:   timePointer(v.Start),
+			End:     timePointer(v.End),
+		}
+		encoded.setBody(body)
+		return encoded, nil
+	case MessageRequest:
+		return eventJSON{Type: eventTypeMessageRequest, Source: v.Source, Target: v.Target, Header: v.Header, Body: v.Body,
+			Start: timePointer(v.Start), End: timePointer(v.End)}, nil
+	case MessageResponse:
+		return eventJSON{Type: eventTypeMessageResponse, Source: v.Source, Target: v.Target, Header: v.Header, Body: v.Body,
+			Start: timePointer(v.Start), End: timePointer(v.End)}, nil
+	case SpanRequest:
+		return eventJSON{Type: eventTypeSpanRequest, Source: v.Source, Target: v.Target, Name: v.Name,
+			TraceID: v.TraceID, SpanID: v.SpanID, Start: timePointer(v.Start), Attributes: v.Attributes}, nil
+	case SpanResponse:
+		return eventJSON{Type: eventTypeSpanResponse, Source: v.Source, Target: v.Target, Name: v.Name,
+			TraceID: v.TraceID, SpanID: v.SpanID, Start: timePointer(v.Start), End: timePointer(v.End), Status: v.Status}, nil
+	case Note:
+		return eventJSON{Type: eventTypeNote, Position: string(v.Position), Participants: v.Participants, Text: v.Text}, nil
+	case Divider:
+		return eventJSON{Type: eventTypeDivider, Text: v.Text}, nil
+	case GroupStart:
+		return eventJSON{Type: eventTypeGroupStart, Kind: string(v.Kind), Text: v.Text}, nil
+	case GroupBranch:
+		return eventJSON{Type: eventTypeGroupBranch, Kind: string(v.Kind), Text: v.Text}, nil
+	case GroupEnd:
+		return eventJSON{Type: eventTypeGroupEnd, Kind: string(v.Kind)}, nil
+	}
+
+	eventTypes.RLock()
+	name, ok := eventTypes.byType[reflect.TypeOf(event)]
+	eventTypes.RUnlock()
+	if !ok {
+		return eventJSON{}, fmt.Errorf("event type %T is not registered, see RegisterEventType", event)
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return eventJSON{}, err
+	}
+	return eventJSON{Type: name, Data: data}, nil
+}
+
+func (r eventJSON) toEvent() (Event, error) {
+	switch r.Type {
+	case eventTypeHttpRequest:
+		body, err := r.body()
+		if err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequest(r.Method, r.URL, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		if len(body) == 0 {
+			req.Body = http.NoBody
+		}
+		if r.Headers != nil {
+			req.Header = r.Headers
+		}
+		if r.Host != "" {
+			req.Host = r.Host
+		}
+		setProto(r.Proto, &req.Proto, &req.ProtoMajor, &req.ProtoMinor)
+		return HttpRequest{Source: r.Source, Target: r.Target, Value: req, Start: timeValue(r.Start), End: timeValue(r.End)}, nil
+	case eventTypeHttpResponse:
+		body, err := r.body()
+		if err != nil {
+			return nil, err
+		}
+		res := &http.Response{
+			StatusCode:    r.Status,
+			Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+			Header:        r.Headers,
+			Body:          ioutil.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+		}
+		if res.Header == nil {
+			res.Header = http.Header{}
+		}
+		setProto(r.Proto, &res.Proto, &res.ProtoMajor, &res.ProtoMinor)
+		return HttpResponse{Source: r.Source, Target: r.Target, Value: res, Start: timeValue(r.Start), End: timeValue(r.End)}, nil
+	case eventTypeMessageRequest:
+		return MessageRequest{Source: r.Source, Target: r.Target, Header: r.Header, Body: r.Body,
+			Start: timeValue(r.Start), End: timeValue(r.End)}, nil
+	case eventTypeMessageResponse:
+		return MessageResponse{Source: r.Source, Target: r.Target, Header: r.Header, Body: r.Body,
+			Start: timeValue(r.Start), End: timeValue(r.End)}, nil
+	case eventTypeSpanRequest:
+		return SpanRequest{Source: r.Source, Target: r.Target, Name: r.Name, TraceID: r.TraceID, SpanID: r.SpanID,
+			Start: timeValue(r.Start), Attributes: r.Attributes}, nil
+	case eventTypeSpanResponse:
+		return SpanResponse{Source: r.Source, Target: r.Target, Name: r.Name, TraceID: r.TraceID, SpanID: r.SpanID,
+			Start: timeValue(r.Start), End: timeValue(r.End), Status: r.Status}, nil
+	case eventTypeNote:
+		return Note{Position: NotePosition(r.Position), Participants: r.Participants, Text: r.Text}, nil
+	case eventTypeDivider:
+		return Divider{Text: r.Text}, nil
+	case eventTypeGroupStart:
+		return GroupStart{Kind: GroupKind(r.Kind), Text: r.Text}, nil
+	case eventTypeGroupBranch:
+		return GroupBranch{Kind: GroupKind(r.Kind), Text: r.Text}, nil
+	case eventTypeGroupEnd:
+		return GroupEnd{Kind: GroupKind(r.Kind)}, nil
+	}
+
+	eventTypes.RLock()
+	t, ok := eventTypes.byName[r.Type]
+	eventTypes.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown event type %q", r.Type)
+	}
+	value := reflect.New(t)
+	if err := json.Unmarshal(r.Data, value.Interface()); err != nil {
+		return nil, err
+	}
+	return value.Elem().Interface().(Event), nil
+}
+
+// setBody stores text bodies as strings and binary bodies as base64
+func (r *eventJSON) setBody(body []byte) {
+	if utf8.Valid(body) {
+		r.Body = string(body)
+	} else {
+		r.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+	}
+}
+
+func (r eventJSON) body() ([]byte, error) {
+	if r.BodyBase64 != "" {
+		return base64.StdEncoding.DecodeString(r.BodyBase64)
+	}
+	return []byte(r.Body), nil
+}
+
+// protoString returns the protocol version of a request or response. Requests and responses built by
+// hand often only set ProtoMajor and ProtoMinor
+func protoString(proto string, major, minor int) string {
+	if proto != "" || major == 0 && minor == 0 {
+		return proto
+	}
+	return fmt.Sprintf("HTTP/%d.%d", major, minor)
+}
+
+// setProto sets the protocol version of a decoded request or response, defaulting to HTTP/1.1 for
+// recordings that do not carry one
+func setProto(proto string, field *string, major, minor *int) {
+	if proto == "" {
+		proto = "HTTP/1.1"
+	}
+	if ma, mi, ok := http.ParseHTTPVersion(proto); ok {
+		*field, *major, *minor = proto, ma, mi
+	}
+}
+
+// timePointer omits unknown times from the JSON recording
+func timePointer(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func timeValue(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
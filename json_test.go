@@ -0,0 +1,182 @@
+package sequence
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type queuePublish struct {
+	Producer string `json:"producer"`
+	Topic    string `json:"topic"`
+}
+
+func (r queuePublish) From() string                { return r.Producer }
+func (r queuePublish) To() string                  { return r.Topic }
+func (r queuePublish) Label() string               { return "publish" }
+func (r queuePublish) IsResponse() bool            { return false }
+func (r queuePublish) LogEntry() (LogEntry, error) { return LogEntry{Header: r.Topic}, nil }
+
+func init() {
+	RegisterEventType("queue_publish", queuePublish{})
+}
+
+func TestDocument_MarshalJSON(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com/posts", strings.NewReader(`{"title":"go"}`))
+	req.Header.Set("Content-Type", "application/json")
+	res := &http.Response{
+		StatusCode: http.StatusCreated,
+		Proto:      "HTTP/1.1",
+		Header:     http.Header{"Content-Type": []string{"image/png"}},
+		Body:       ioutil.NopCloser(strings.NewReader("\x89PNG\xff")),
+	}
+	document := NewDocument().AddTitle("posts").AddDiagram(NewDiagram().
+		AddTitle("create post").
+		AddHttpRequest(HttpRequest{Source: "app", Target: "example.com", Value: req}).
+		AddHttpResponse(HttpResponse{Source: "example.com", Target: "app", Value: res}).
+		AddMessageRequest(MessageRequest{Source: "app", Target: "db", Header: "SELECT 1"}))
+
+	data, err := json.Marshal(document)
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{
+		"version": 1,
+		"title": "posts",
+		"diagrams": [{
+			"title": "create post",
+			"events": [
+				{"type": "http_request", "source": "app", "target": "example.com", "method": "POST",
+				 "url": "http://example.com/posts", "proto": "HTTP/1.1",
+				 "headers": {"Content-Type": ["application/json"]}, "body": "{\"title\":\"go\"}"},
+				{"type": "http_response", "source": "example.com", "target": "app", "status": 201, "proto": "HTTP/1.1",
+				 "headers": {"Content-Type": ["image/png"]}, "bodyBase64": "iVBOR/8="},
+				{"type": "message_request", "source": "app", "target": "db", "header": "SELECT 1"}
+			]
+		}]
+	}`, string(data))
+}
+
+func TestDocument_MarshalJSON_LeavesBodiesReadable(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com/posts", strings.NewReader("hello"))
+	document := NewDocument().AddDiagram(NewDiagram().
+		AddHttpRequest(HttpRequest{Source: "app", Target: "example.com", Value: req}))
+
+	_, err := json.Marshal(document)
+
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(req.Body)
+	assert.Equal(t, "hello", string(body))
+}
+
+func TestDocument_UnmarshalJSON_RoundTrips(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/posts/1", nil)
+	req.Header.Set("Accept", "application/json")
+	res := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/octet-stream"}},
+		Body:       ioutil.NopCloser(strings.NewReader("\x00\x01\xff")),
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+	}
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	document := NewDocument().AddTitle("posts").AddDiagram(NewDiagram().
+		AddTitle("get post").
+		AddSubTitle("by id").
+		AddHttpRequest(HttpRequest{Source: "app", Target: "example.com", Value: req}).
+		AddHttpResponse(HttpResponse{Source: "example.com", Target: "app", Value: res}).
+		AddEvent(SpanRequest{Source: "app", Target: "db", Name: "query", TraceID: "t1", SpanID: "s1", Start: start}).
+		AddEvent(SpanResponse{Source: "db", Target: "app", Name: "query", TraceID: "t1", SpanID: "s1", End: start.Add(time.Second), Status: -1}).
+		AddEvent(queuePublish{Producer: "app", Topic: "posts"}))
+	document.MetaJSON = `{"host":"ci"}`
+	data, _ := json.Marshal(document)
+
+	var decoded Document
+	err := json.Unmarshal(data, &decoded)
+
+	assert.Nil(t, err)
+	again, _ := json.Marshal(&decoded)
+	assert.JSONEq(t, string(data), string(again))
+	assert.Equal(t, "posts", decoded.Title)
+	assert.Equal(t, `{"host":"ci"}`, string(decoded.MetaJSON))
+	assert.Len(t, decoded.Diagrams, 1)
+	diagram := decoded.Diagrams[0]
+	assert.Equal(t, "get post", diagram.Title)
+	assert.Equal(t, "by id", diagram.SubTitle)
+	assert.Len(t, diagram.Events, 5)
+
+	decodedReq := diagram.Events[0].(HttpRequest)
+	assert.Equal(t, "GET http://example.com/posts/1", decodedReq.Label())
+	assert.Equal(t, "application/json", decodedReq.Value.Header.Get("Accept"))
+	decodedRes := diagram.Events[1].(HttpResponse)
+	assert.Equal(t, http.StatusOK, decodedRes.StatusCode())
+	body, _ := ioutil.ReadAll(decodedRes.Value.Body)
+	assert.Equal(t, "\x00\x01\xff", string(body))
+	assert.Equal(t, SpanRequest{Source: "app", Target: "db", Name: "query", TraceID: "t1", SpanID: "s1", Start: start}, diagram.Events[2])
+	assert.Equal(t, start.Add(time.Second), diagram.Events[3].(SpanResponse).End)
+	assert.Equal(t, queuePublish{Producer: "app", Topic: "posts"}, diagram.Events[4])
+}
+
+func TestDiagram_UnmarshalJSON_KeepsProtocolVersion(t *testing.T) {
+	res := &http.Response{StatusCode: http.StatusOK, ProtoMajor: 2, Header: http.Header{}, Body: http.NoBody}
+	data, err := json.Marshal(NewDiagram().AddHttpRequest(aRequest()).AddHttpResponse(HttpResponse{Value: res}))
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"proto":"HTTP/2.0"`)
+
+	var diagram Diagram
+	err = json.Unmarshal(data, &diagram)
+
+	assert.Nil(t, err)
+	entry, _ := diagram.Events[1].LogEntry()
+	assert.True(t, strings.HasPrefix(entry.Header, "HTTP/2.0 200 OK"), entry.Header)
+}
+
+func TestDiagram_UnmarshalJSON_DefaultsToHTTP11(t *testing.T) {
+	var diagram Diagram
+	err := json.Unmarshal([]byte(`{"version": 1, "events": [{"type": "http_response", "status": 200}]}`), &diagram)
+
+	assert.Nil(t, err)
+	entry, _ := diagram.Events[0].LogEntry()
+	assert.True(t, strings.HasPrefix(entry.Header, "HTTP/1.1 200 OK"), entry.Header)
+}
+
+func TestDocument_UnmarshalJSON_RejectsNewerVersion(t *testing.T) {
+	var document Document
+
+	err := json.Unmarshal([]byte(`{"version": 2, "diagrams": []}`), &document)
+
+	assert.EqualError(t, err, "unsupported document version 2")
+}
+
+func TestDiagram_UnmarshalJSON_RejectsUnknownEventType(t *testing.T) {
+	var diagram Diagram
+
+	err := json.Unmarshal([]byte(`{"events": [{"type": "carrier_pigeon"}]}`), &diagram)
+
+	assert.EqualError(t, err, `event 1: unknown event type "carrier_pigeon"`)
+}
+
+func TestDiagram_MarshalJSON_RejectsUnregisteredEventType(t *testing.T) {
+	diagram := NewDiagram().AddEvent(kafkaPublish{topic: "posts"})
+
+	_, err := json.Marshal(diagram)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is not registered, see RegisterEventType")
+}
+
+func TestDocument_UnmarshalJSON_RoundTripsTiming(t *testing.T) {
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	response := MessageResponse{Source: "app", Target: "cli", Header: "ok", Start: start, End: start.Add(time.Second)}
+	data, _ := json.Marshal(NewDocument().AddDiagram(NewDiagram().AddMessageResponse(response)))
+
+	var decoded Document
+	err := json.Unmarshal(data, &decoded)
+
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"start":"2020-01-02T03:04:05Z","end":"2020-01-02T03:04:06Z"`)
+	assert.Equal(t, response, decoded.Diagrams[0].Events[0])
+}
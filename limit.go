@@ -0,0 +1,124 @@
+package sequence
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"unicode/utf8"
+)
+
+// BodySidecar stores the full body of each truncated log entry in a file that the report links to
+type BodySidecar struct {
+	// Dir is the directory the files are written to
+	Dir string
+	// URL is the location of Dir relative to the report, used to link to each file
+	URL string
+}
+
+// LimitBodySize truncates bodies longer than max bytes in the log table. Zero means no limit
+func (r *Diagram) LimitBodySize(max int) *Diagram {
+	r.MaxBodySize = max
+	return r
+}
+
+// LimitBodySize truncates bodies longer than max bytes in every diagram that has no limit of its own
+func (r *Document) LimitBodySize(max int) *Document {
+	r.MaxBodySize = max
+	return r
+}
+
+// StoreFullBodies writes the full body of each truncated log entry to a file in dir, which the HTML
+// report links to at url
+func (r *Document) StoreFullBodies(dir, url string) *Document {
+	r.Sidecar = &BodySidecar{Dir: dir, URL: url}
+	return r
+}
+
+// storeBody returns the function that stores the full bodies of the nth diagram, or nil when the
+// document does not store them
+func (r *Document) storeBody(diagram int) func(entry int, body string) (string, error) {
+	if r.Sidecar == nil {
+		return nil
+	}
+	return r.Sidecar.store(diagram)
+}
+
+// store returns a function that writes the bodies of a diagram's log entries and returns their links
+func (r *BodySidecar) store(diagram int) func(entry int, body string) (string, error) {
+	return func(entry int, body string) (string, error) {
+		if err := os.MkdirAll(r.Dir, 0755); err != nil {
+			return "", err
+		}
+		name := fmt.Sprintf("diagram%d_%d.txt", diagram, entry)
+		if err := ioutil.WriteFile(filepath.Join(r.Dir, name), []byte(body), 0644); err != nil {
+			return "", err
+		}
+		return path.Join(r.URL, name), nil
+	}
+}
+
+// bodyLimiter is implemented by events that cut their bodies to a size before decoding and formatting
+// them, so long bodies are not decoded and formatted in full only to be cut afterwards
+type bodyLimiter interface {
+	limitedLogEntry(max int) (LogEntry, bool, error)
+}
+
+// limitedLogEntry returns the log entry of the event with its body cut to at most max bytes, and reports
+// whether the body was cut
+func limitedLogEntry(event Event, max int) (LogEntry, bool, error) {
+	if limiter, ok := event.(bodyLimiter); ok {
+		return limiter.limitedLogEntry(max)
+	}
+	entry, err := event.LogEntry()
+	if err != nil {
+		return LogEntry{}, false, err
+	}
+	var truncated bool
+	entry.Body, truncated = truncateBody(entry.Body, max)
+	return entry, truncated, nil
+}
+
+// truncateBody cuts the body to at most max bytes, ending on a whole character, and notes how many
+// bytes were cut. It reports whether the body was truncated
+func truncateBody(body string, max int) (string, bool) {
+	if max <= 0 || len(body) <= max {
+		return body, false
+	}
+	return truncatedText([]byte(body[:max]), len(body)), true
+}
+
+// truncatedContent shows the kept start of a body that was cut, where size is the size of the whole body.
+// The body is not formatted since formatters need all of it
+func truncatedContent(kept []byte, size int, contentType string) (string, string) {
+	if isBinary(kept[:wholeCharacters(kept)]) {
+		shown := kept
+		if len(shown) > hexdumpLimit {
+			shown = shown[:hexdumpLimit]
+		}
+		return fmt.Sprintf("binary, %d bytes\n\n%s… %d more bytes", size, hex.Dump(shown), size-len(shown)), ""
+	}
+	return truncatedText(kept, size), bodyFormatterFor(contentType, nil).Language
+}
+
+// truncatedText ends the kept start of a body on a whole character and notes how many of the size bytes
+// of the whole body are not shown
+func truncatedText(kept []byte, size int) string {
+	cut := wholeCharacters(kept)
+	return fmt.Sprintf("%s\n… %d more bytes", kept[:cut], size-cut)
+}
+
+// wholeCharacters returns the length of the body without a character cut off at its end
+func wholeCharacters(body []byte) int {
+	for i := len(body) - 1; i >= 0 && i >= len(body)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(body[i]) {
+			if !utf8.FullRune(body[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(body)
+}
@@ -0,0 +1,123 @@
+package sequence
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiagram_LimitBodySize_TruncatesLongBodies(t *testing.T) {
+	model, err := aDiagramWithBody("abcdefghij").LimitBodySize(4).BuildModel()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "abcd\n… 6 more bytes", model.LogEntries[0].Body)
+	assert.Equal(t, "", model.LogEntries[0].BodyURL)
+}
+
+func TestDiagram_LimitBodySize_KeepsShortBodies(t *testing.T) {
+	model, err := aDiagramWithBody("abcd").LimitBodySize(4).BuildModel()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "abcd", model.LogEntries[0].Body)
+}
+
+func TestDocument_LimitBodySize_AppliesToDiagramsWithoutALimit(t *testing.T) {
+	document := NewDocument().
+		AddDiagram(aDiagramWithBody("abcdefghij")).
+		AddDiagram(aDiagramWithBody("abcdefghij").LimitBodySize(8)).
+		LimitBodySize(2)
+
+	model, err := document.BuildModel()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "ab\n… 8 more bytes", model.Diagrams[0].LogEntries[0].Body)
+	assert.Equal(t, "abcdefgh\n… 2 more bytes", model.Diagrams[1].LogEntries[0].Body)
+}
+
+func TestDocument_StoreFullBodies_WritesSidecarFiles(t *testing.T) {
+	dir := t.TempDir()
+	document := NewDocument().
+		AddDiagram(aDiagramWithBody("short")).
+		AddDiagram(aDiagramWithBody(strings.Repeat("x", 20))).
+		LimitBodySize(10).
+		StoreFullBodies(dir, "bodies")
+
+	html, err := document.RenderHTML()
+
+	assert.Nil(t, err)
+	assert.Contains(t, html, `<a href="bodies/diagram2_1.txt">Full body</a>`)
+	assert.NotContains(t, html, "diagram1_1.txt")
+	body, _ := ioutil.ReadFile(filepath.Join(dir, "diagram2_1.txt"))
+	assert.Equal(t, strings.Repeat("x", 20), string(body))
+}
+
+func TestDiagram_LimitBodySize_CutsHttpBodiesBeforeFormatting(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com/posts", strings.NewReader(`{"id":1,"title":"hello"}`))
+	req.Header.Set("Content-Type", "application/json")
+	diagram := NewDiagram().
+		AddHttpRequest(HttpRequest{Source: "cli", Target: "app", Value: req}).
+		AddHttpResponse(HttpResponse{Source: "app", Target: "cli", Value: &http.Response{StatusCode: http.StatusOK}}).
+		LimitBodySize(7)
+
+	model, err := diagram.BuildModel()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"id\":1\n… 17 more bytes", model.LogEntries[0].Body)
+	assert.Equal(t, "json", model.LogEntries[0].Language)
+}
+
+func TestDiagram_LimitBodySize_CountsTheDecodedBody(t *testing.T) {
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	gz.Write([]byte(strings.Repeat("x", 1000)))
+	gz.Close()
+	res := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Encoding": []string{"gzip"}},
+		Body:       ioutil.NopCloser(bytes.NewReader(gzipped.Bytes())),
+	}
+	dir := t.TempDir()
+	document := NewDocument().
+		AddDiagram(NewDiagram().
+			AddMessageRequest(MessageRequest{Source: "cli", Target: "app", Header: "download"}).
+			AddHttpResponse(HttpResponse{Source: "app", Target: "cli", Value: res})).
+		LimitBodySize(4).
+		StoreFullBodies(dir, "bodies")
+
+	model, err := document.BuildModel()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "xxxx\n… 996 more bytes", model.Diagrams[0].LogEntries[1].Body)
+	body, _ := ioutil.ReadFile(filepath.Join(dir, "diagram1_2.txt"))
+	assert.Equal(t, strings.Repeat("x", 1000), string(body))
+}
+
+func TestTruncateBody_EndsOnWholeCharacter(t *testing.T) {
+	truncated, ok := truncateBody("aé", 2)
+
+	assert.True(t, ok)
+	assert.Equal(t, "a\n… 2 more bytes", truncated)
+}
+
+func TestDocument_StoreFullBodies_LinksFromMarkdown(t *testing.T) {
+	document := NewDocument().
+		AddDiagram(aDiagramWithBody("abcdefghij")).
+		LimitBodySize(4).
+		StoreFullBodies(t.TempDir(), "bodies")
+
+	markdown, err := document.RenderMarkdown()
+
+	assert.Nil(t, err)
+	assert.Contains(t, markdown, "```\nabcd\n… 6 more bytes\n```\n\n[Full body](bodies/diagram1_1.txt)\n")
+}
+
+func aDiagramWithBody(body string) *Diagram {
+	return NewDiagram().
+		AddMessageRequest(MessageRequest{Source: "cli", Target: "app", Header: "upload", Body: body}).
+		AddMessageResponse(MessageResponse{Source: "app", Target: "cli", Header: "ok"})
+}
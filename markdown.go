@@ -0,0 +1,95 @@
+package sequence
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// RenderMarkdown renders the document as markdown with each diagram in a fenced mermaid block,
+// followed by the wire representation of each request and response
+func (r *Document) RenderMarkdown() (string, error) {
+	var out bytes.Buffer
+	if r.Title != "" {
+		out.WriteString(fmt.Sprintf("# %s\n\n", r.Title))
+	}
+	if r.Description != "" {
+		out.WriteString(fmt.Sprintf("%s\n\n", r.Description))
+	}
+
+	for i, d := range r.diagrams() {
+		model, err := d.buildModel(r.storeBody(i + 1))
+		if err != nil {
+			return "", err
+		}
+		mermaid, err := d.RenderMermaid()
+		if err != nil {
+			return "", err
+		}
+
+		if model.Title != "" {
+			out.WriteString(fmt.Sprintf("## %s\n\n", model.Title))
+		}
+		if model.StatusCode != -1 {
+			out.WriteString(fmt.Sprintf("**Status:** %d\n\n", model.StatusCode))
+		}
+		if model.TotalTime != "" {
+			out.WriteString(fmt.Sprintf("**Total time:** %s\n\n", model.TotalTime))
+		}
+		if model.SubTitle != "" {
+			out.WriteString(fmt.Sprintf("%s\n\n", model.SubTitle))
+		}
+		out.WriteString(fence(mermaid, "mermaid"))
+		out.WriteString("\n")
+
+		out.WriteString("### Request/Response wire representation\n\n")
+		number := 0
+		for _, entry := range model.LogEntries {
+			if entry.Annotation != "" {
+				if entry.Header == "" {
+					out.WriteString(fmt.Sprintf("> *%s*\n\n", entry.Annotation))
+				} else {
+					out.WriteString(fmt.Sprintf("> *%s:* %s\n\n", entry.Annotation, strings.Replace(entry.Header, "\n", "\n> ", -1)))
+				}
+				continue
+			}
+			number++
+			if entry.Latency != "" {
+				out.WriteString(fmt.Sprintf("**(%d)** %s\n\n", number, entry.Latency))
+			} else {
+				out.WriteString(fmt.Sprintf("**(%d)**\n\n", number))
+			}
+			out.WriteString(fence(entry.Header, ""))
+			if entry.Body != "" {
+				out.WriteString("\n")
+				out.WriteString(fence(entry.Body, entry.Language))
+				if entry.BodyURL != "" {
+					out.WriteString(fmt.Sprintf("\n[Full body](%s)\n", entry.BodyURL))
+				}
+			}
+			out.WriteString("\n")
+		}
+	}
+
+	return out.String(), nil
+}
+
+// fence wraps content in a fenced code block that is longer than any run of backticks in the content
+func fence(content, lang string) string {
+	longest, run := 0, 0
+	for _, c := range content {
+		if c == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	marker := "```"
+	if longest >= len(marker) {
+		marker = strings.Repeat("`", longest+1)
+	}
+	return fmt.Sprintf("%s%s\n%s\n%s\n", marker, lang, strings.TrimRight(content, "\r\n"), marker)
+}
@@ -0,0 +1,49 @@
+package sequence
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestDocument_RenderMarkdown(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodPost, "http://example.com/posts", bytes.NewBufferString("hello"))
+	diagram := NewDiagram().
+		AddTitle("Create post").
+		AddSubTitle("creates a post").
+		AddHttpRequest(HttpRequest{Source: "consumer", Target: "app", Value: request}).
+		AddHttpResponse(HttpResponse{Source: "app", Target: "consumer", Value: aResponse().Value})
+
+	markdown, err := NewDocument().
+		AddTitle("Posts API").
+		AddDescription("description").
+		AddDiagram(diagram).
+		RenderMarkdown()
+
+	assert.Nil(t, err)
+	assert.Contains(t, markdown, "# Posts API\n\ndescription\n\n## Create post\n\n**Status:** 204\n\ncreates a post\n\n")
+	assert.Contains(t, markdown, "```mermaid\nsequenceDiagram\n    participant consumer\n")
+	assert.Contains(t, markdown, "    app-->>consumer: (2) 204\n```\n")
+	assert.Contains(t, markdown, "**(1)**\n\n```\nPOST /posts HTTP/1.1")
+	assert.Contains(t, markdown, "```\nhello\n```\n")
+	assert.Contains(t, markdown, "**(2)**\n\n```\nHTTP/0.0 204 No Content")
+}
+
+func TestDocument_RenderMarkdown_CanRenderAfterHTML(t *testing.T) {
+	request, _ := http.NewRequest(http.MethodPost, "http://example.com/posts", bytes.NewBufferString("hello"))
+	document := NewDocument().AddDiagram(NewDiagram().
+		AddHttpRequest(HttpRequest{Value: request}).
+		AddHttpResponse(aResponse()))
+
+	_, err := document.RenderHTML()
+	assert.Nil(t, err)
+	markdown, err := document.RenderMarkdown()
+
+	assert.Nil(t, err)
+	assert.Contains(t, markdown, "```\nhello\n```\n")
+}
+
+func TestFence_UsesLongerMarkerThanContent(t *testing.T) {
+	assert.Equal(t, "````json\na```b\n````\n", fence("a```b", "json"))
+}
@@ -0,0 +1,118 @@
+package sequence
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// MermaidDiagram builds a Mermaid sequenceDiagram, which GitHub and GitLab render natively in markdown
+type MermaidDiagram struct {
+	data         dslRows
+	count        int
+	participants participantAliases
+}
+
+func (r *MermaidDiagram) AddParticipant(participant Participant) {
+	r.participants.declare(participant)
+}
+
+func (r *MermaidDiagram) AddRequestRow(source, target, description string) {
+	r.addRow("->>", source, target, description)
+}
+
+func (r *MermaidDiagram) AddResponseRow(source, target, description string) {
+	r.addRow("-->>", source, target, description)
+}
+
+func (r *MermaidDiagram) addRow(operation, source, target, description string) {
+	r.count += 1
+	r.data.add(fmt.Sprintf("    %s%s%s: (%d) %s\n",
+		r.participants.alias(source),
+		operation,
+		r.participants.alias(target),
+		r.count,
+		escapeMermaid(description)))
+}
+
+func (r *MermaidDiagram) AddNote(position NotePosition, participants []string, text string) {
+	aliases := make([]string, len(participants))
+	for i, name := range participants {
+		aliases[i] = r.participants.alias(name)
+	}
+	r.data.add(fmt.Sprintf("    Note %s %s: %s\n", position, strings.Join(aliases, ","), escapeMermaid(text)))
+}
+
+// AddDivider draws the divider as a note over every participant, since Mermaid has no dividers
+func (r *MermaidDiagram) AddDivider(text string) {
+	r.data.addSpanning(fmt.Sprintf("== %s ==", escapeMermaid(text)))
+}
+
+func (r *MermaidDiagram) AddGroupStart(kind GroupKind, label string) {
+	r.data.add(fmt.Sprintf("    %s\n", groupLine(string(kind), escapeMermaid(label))))
+}
+
+func (r *MermaidDiagram) AddGroupBranch(kind GroupKind, label string) {
+	r.data.add(fmt.Sprintf("    %s\n", groupLine(branchKeyword(kind), escapeMermaid(label))))
+}
+
+func (r *MermaidDiagram) AddGroupEnd(kind GroupKind) {
+	r.data.add("    end\n")
+}
+
+func (r *MermaidDiagram) ToString() string {
+	var out bytes.Buffer
+	out.WriteString("sequenceDiagram\n")
+	for _, name := range r.participants.names {
+		participant := r.participants.participant(name)
+		alias := r.participants.alias(name)
+		keyword := "participant"
+		if participant.Kind == ParticipantActor {
+			keyword = "actor"
+		}
+		line := fmt.Sprintf("    %s %s\n", keyword, alias)
+		if alias != participant.Name {
+			line = fmt.Sprintf("    %s %s as %s\n", keyword, alias, escapeMermaid(participant.Name))
+		}
+		// Mermaid colors participants by drawing a box around them
+		if participant.Color != "" {
+			line = fmt.Sprintf("    box %s\n%s    end\n", participant.Color, line)
+		}
+		out.WriteString(line)
+	}
+	r.data.write(&out, func(text string) string {
+		names := r.participants.names
+		switch len(names) {
+		case 0:
+			return ""
+		case 1:
+			return fmt.Sprintf("    Note over %s: %s\n", r.participants.alias(names[0]), text)
+		}
+		return fmt.Sprintf("    Note over %s,%s: %s\n", r.participants.alias(names[0]), r.participants.alias(names[len(names)-1]), text)
+	})
+	return out.String()
+}
+
+// RenderMermaid renders the diagram as a Mermaid sequenceDiagram
+func (r *Diagram) RenderMermaid() (string, error) {
+	mermaid := &MermaidDiagram{}
+	if err := r.writeDSL(mermaid); err != nil {
+		return "", err
+	}
+	return mermaid.ToString(), nil
+}
+
+// RenderMermaid renders every diagram of the document as a Mermaid sequenceDiagram, separated by a blank line
+func (r *Document) RenderMermaid() (string, error) {
+	return r.renderEach((*Diagram).RenderMermaid)
+}
+
+// escapeMermaid replaces characters that terminate or corrupt a Mermaid statement with entity codes
+func escapeMermaid(text string) string {
+	return strings.NewReplacer(
+		"#", "#35;",
+		";", "#59;",
+		"\r\n", " ",
+		"\n", " ",
+	).Replace(text)
+}
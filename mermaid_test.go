@@ -0,0 +1,84 @@
+package sequence
+
+import (
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestMermaidDiagram_GeneratesDSL(t *testing.T) {
+	mermaid := MermaidDiagram{}
+	mermaid.AddRequestRow("A", "B", "request1")
+	mermaid.AddRequestRow("B", "C", "request2")
+	mermaid.AddResponseRow("C", "B", "response1")
+	mermaid.AddResponseRow("B", "A", "response2")
+
+	dsl := mermaid.ToString()
+
+	assert.Equal(t, `sequenceDiagram
+    participant A
+    participant B
+    participant C
+    A->>B: (1) request1
+    B->>C: (2) request2
+    C-->>B: (3) response1
+    B-->>A: (4) response2
+`, dsl)
+}
+
+func TestMermaidDiagram_AliasesParticipantsThatAreNotIdentifiers(t *testing.T) {
+	mermaid := MermaidDiagram{}
+	mermaid.AddRequestRow("app", "example.com:443", "GET /")
+	mermaid.AddRequestRow("app", "example_com_443", "GET /")
+
+	dsl := mermaid.ToString()
+
+	assert.Equal(t, `sequenceDiagram
+    participant app
+    participant example_com_443 as example.com:443
+    participant example_com_443_2 as example_com_443
+    app->>example_com_443: (1) GET /
+    app->>example_com_443_2: (2) GET /
+`, dsl)
+}
+
+func TestMermaidDiagram_EscapesDescription(t *testing.T) {
+	mermaid := MermaidDiagram{}
+	mermaid.AddRequestRow("A", "B", "a;b#c\nd")
+
+	assert.Contains(t, mermaid.ToString(), "A->>B: (1) a#59;b#35;c d\n")
+}
+
+func TestDiagram_RenderMermaid(t *testing.T) {
+	diagram := NewDiagram().
+		AddHttpRequest(HttpRequest{Source: "consumer", Target: "app", Value: aRequest().Value}).
+		AddHttpResponse(HttpResponse{Source: "app", Target: "consumer", Value: aResponse().Value})
+
+	dsl, err := diagram.RenderMermaid()
+
+	assert.Nil(t, err)
+	assert.Equal(t, `sequenceDiagram
+    participant consumer
+    participant app
+    consumer->>app: (1) GET http://example.com/abcdef
+    app-->>consumer: (2) 204
+`, dsl)
+}
+
+func TestDocument_RenderMermaid_AppliesDocumentRedaction(t *testing.T) {
+	connect := NewDiagram().
+		AddMessageRequest(MessageRequest{Source: "app", Target: "db", Header: "connect password=secret"}).
+		AddMessageResponse(MessageResponse{Source: "db", Target: "app", Header: "ok"})
+	document := NewDocument().
+		AddRedaction(NewRedaction().WithMask("***").RedactPatterns(regexp.MustCompile(`password=(\S+)`))).
+		AddDiagram(connect).
+		AddDiagram(connect)
+
+	dsl, err := document.RenderMermaid()
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, strings.Count(dsl, "app->>db: (1) connect password=***\n"))
+	assert.Contains(t, dsl, "\n\nsequenceDiagram\n")
+	assert.NotContains(t, dsl, "secret")
+}
@@ -0,0 +1,210 @@
+package sequence
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+)
+
+// RecordingHandler wraps an http.Handler and records the inbound request and the final response on a
+// Diagram. Neither body is consumed, so the wrapped handler and the client behave as they would without it.
+// The request body is recorded as the handler reads it, and the part the handler leaves unread is read
+// once the handler returns, so a request rejected before its body is read is recorded whole
+type RecordingHandler struct {
+	// Handler serves the request
+	Handler http.Handler
+	// Diagram receives the recorded events
+	Diagram *Diagram
+	// Source names the caller. Defaults to "consumer"
+	Source string
+	// Target names the application serving the request. Defaults to "app"
+	Target string
+}
+
+func NewRecordingHandler(diagram *Diagram, handler http.Handler) *RecordingHandler {
+	return &RecordingHandler{Diagram: diagram, Handler: handler, Source: "consumer", Target: "app"}
+}
+
+// RecordingMiddleware returns middleware that records requests on the diagram, for routers that accept
+// func(http.Handler) http.Handler such as gorilla and chi
+func RecordingMiddleware(diagram *Diagram) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return NewRecordingHandler(diagram, next)
+	}
+}
+
+// WithParticipants sets the names of the caller and of the application serving the request
+func (r *RecordingHandler) WithParticipants(source, target string) *RecordingHandler {
+	r.Source = source
+	r.Target = target
+	return r
+}
+
+func (r *RecordingHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+	recordedReq := req.Clone(req.Context())
+	// the handler reads the body itself, and sees any error reading it
+	var body *recordingBody
+	if req.Body != nil && req.Body != http.NoBody {
+		recordedReq.Body = http.NoBody
+		body = newRecordingBody(req.Body, func(b []byte) {
+			recordedReq.Body = ioutil.NopCloser(bytes.NewReader(b))
+		})
+		req.Body = body
+	}
+	// the recorded request is rendered as a client request, which needs an absolute URL
+	recordedReq.RequestURI = ""
+	recordedReq.URL.Host = req.Host
+	recordedReq.URL.Scheme = "http"
+	if req.TLS != nil {
+		recordedReq.URL.Scheme = "https"
+	}
+	r.Diagram.AddHttpRequest(HttpRequest{Source: r.Source, Target: r.Target, Value: recordedReq, Start: start})
+
+	recorder := &responseRecorder{ResponseWriter: w}
+	r.Handler.ServeHTTP(recorder.writer(), req)
+	if body != nil {
+		// the handler is done with the body, so the part it did not read is recorded too
+		io.Copy(ioutil.Discard, body)
+		body.finish()
+	}
+
+	r.Diagram.AddHttpResponse(HttpResponse{Source: r.Target, Target: r.Source, Value: recorder.result(recordedReq), Start: start, End: time.Now()})
+}
+
+// responseRecorder passes the response through to the client while keeping a copy of it
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+		r.header = r.ResponseWriter.Header().Clone()
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the wrapped writer, so code that unwraps response writers can reach the original
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+type (
+	flushFunc  func()
+	hijackFunc func() (net.Conn, *bufio.ReadWriter, error)
+	pushFunc   func(target string, opts *http.PushOptions) error
+)
+
+func (f flushFunc) Flush() { f() }
+
+func (f hijackFunc) Hijack() (net.Conn, *bufio.ReadWriter, error) { return f() }
+
+func (f pushFunc) Push(target string, opts *http.PushOptions) error { return f(target, opts) }
+
+// writer returns the recorder as a writer implementing the optional http.Flusher, http.Hijacker and
+// http.Pusher interfaces that the wrapped writer implements, so handlers that stream, push or upgrade
+// the connection behave as they would without recording
+func (r *responseRecorder) writer() http.ResponseWriter {
+	_, flusher := r.ResponseWriter.(http.Flusher)
+	_, hijacker := r.ResponseWriter.(http.Hijacker)
+	_, pusher := r.ResponseWriter.(http.Pusher)
+	flush, hijack, push := flushFunc(r.flush), hijackFunc(r.hijack), pushFunc(r.push)
+	switch {
+	case flusher && hijacker && pusher:
+		return struct {
+			*responseRecorder
+			http.Flusher
+			http.Hijacker
+			http.Pusher
+		}{r, flush, hijack, push}
+	case flusher && hijacker:
+		return struct {
+			*responseRecorder
+			http.Flusher
+			http.Hijacker
+		}{r, flush, hijack}
+	case flusher && pusher:
+		return struct {
+			*responseRecorder
+			http.Flusher
+			http.Pusher
+		}{r, flush, push}
+	case hijacker && pusher:
+		return struct {
+			*responseRecorder
+			http.Hijacker
+			http.Pusher
+		}{r, hijack, push}
+	case flusher:
+		return struct {
+			*responseRecorder
+			http.Flusher
+		}{r, flush}
+	case hijacker:
+		return struct {
+			*responseRecorder
+			http.Hijacker
+		}{r, hijack}
+	case pusher:
+		return struct {
+			*responseRecorder
+			http.Pusher
+		}{r, push}
+	}
+	return r
+}
+
+func (r *responseRecorder) flush() {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.ResponseWriter.(http.Flusher).Flush()
+}
+
+// hijack hands the connection to the handler. Anything written to it is not recorded, and the response
+// is recorded as switching protocols unless the handler wrote a status first
+func (r *responseRecorder) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := r.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil && r.status == 0 {
+		r.status = http.StatusSwitchingProtocols
+		r.header = r.ResponseWriter.Header().Clone()
+	}
+	return conn, rw, err
+}
+
+func (r *responseRecorder) push(target string, opts *http.PushOptions) error {
+	return r.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+func (r *responseRecorder) result(req *http.Request) *http.Response {
+	status, header := r.status, r.header
+	if status == 0 {
+		status = http.StatusOK
+		header = r.ResponseWriter.Header().Clone()
+	}
+	return &http.Response{
+		StatusCode:    status,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(r.body.Bytes())),
+		ContentLength: int64(r.body.Len()),
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Request:       req,
+	}
+}
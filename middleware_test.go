@@ -0,0 +1,185 @@
+package sequence
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRecordingHandler_RecordsRequestAndResponse(t *testing.T) {
+	diagram := NewDiagram()
+	handler := NewRecordingHandler(diagram, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(append([]byte("created "), body...))
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/posts", bytes.NewBufferString("hello"))
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusCreated, res.Code)
+	assert.Equal(t, "created hello", res.Body.String())
+	model, err := diagram.BuildModel()
+	assert.Nil(t, err)
+	assert.Len(t, model.LogEntries, 2)
+	assert.Contains(t, model.LogEntries[0].Header, "POST /posts HTTP/1.1")
+	assert.Equal(t, "hello", model.LogEntries[0].Body)
+	assert.Contains(t, model.LogEntries[1].Header, "Content-Type: text/plain")
+	assert.Equal(t, "created hello", model.LogEntries[1].Body)
+	assert.Equal(t, http.StatusCreated, model.StatusCode)
+	assert.Equal(t, "consumer", diagram.Events[0].From())
+	assert.Equal(t, "app", diagram.Events[1].From())
+}
+
+func TestRecordingHandler_PassesRequestBodyErrorsToHandler(t *testing.T) {
+	diagram := NewDiagram()
+	handler := NewRecordingHandler(diagram, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := ioutil.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		}
+	}))
+	res := httptest.NewRecorder()
+
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/posts", &failingBody{}))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, res.Code)
+	assert.Equal(t, "read failed\n", res.Body.String())
+	assert.Len(t, diagram.Events, 2)
+	assert.Equal(t, http.StatusUnprocessableEntity, diagram.Events[1].(HttpResponse).Value.StatusCode)
+}
+
+func TestRecordingHandler_RecordsTheRequestBodyTheHandlerPartlyReads(t *testing.T) {
+	diagram := NewDiagram()
+	handler := NewRecordingHandler(diagram, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefix := make([]byte, 4)
+		r.Body.Read(prefix)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/posts", bytes.NewBufferString("hello world")))
+
+	model, err := diagram.BuildModel()
+	assert.Nil(t, err)
+	assert.Equal(t, "hello world", model.LogEntries[0].Body)
+}
+
+func TestRecordingHandler_RecordsTheRequestBodyTheHandlerIgnores(t *testing.T) {
+	diagram := NewDiagram()
+	handler := NewRecordingHandler(diagram, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/posts", bytes.NewBufferString("title=go"))
+	req.Header.Set("Content-Type", "text/plain")
+
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	model, err := diagram.BuildModel()
+	assert.Nil(t, err)
+	assert.Equal(t, "title=go", model.LogEntries[0].Body)
+	assert.Equal(t, http.StatusUnsupportedMediaType, model.StatusCode)
+}
+
+func TestRecordingHandler_RecordsOutboundCallsBetweenRequestAndResponse(t *testing.T) {
+	diagram := NewDiagram()
+	client := &http.Client{Transport: NewRecordingTransport(diagram).
+		WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+		}))}
+	handler := NewRecordingHandler(diagram, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client.Get("http://example.com/users")
+		w.WriteHeader(http.StatusNoContent)
+	})).WithParticipants("browser", "api")
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/posts", nil))
+
+	assert.Len(t, diagram.Events, 4)
+	assert.Equal(t, "browser", diagram.Events[0].From())
+	assert.Equal(t, "example.com", diagram.Events[1].To())
+	assert.Equal(t, "example.com", diagram.Events[2].From())
+	assert.Equal(t, "browser", diagram.Events[3].To())
+}
+
+func TestRecordingHandler_DefaultsToStatusOK(t *testing.T) {
+	diagram := NewDiagram()
+	handler := RecordingMiddleware(diagram)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	model, err := diagram.BuildModel()
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, model.StatusCode)
+}
+
+func TestRecordingHandler_RecordsTiming(t *testing.T) {
+	diagram := NewDiagram()
+	handler := NewRecordingHandler(diagram, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	req, res := diagram.Events[0].(HttpRequest), diagram.Events[1].(HttpResponse)
+	assert.False(t, req.Start.IsZero())
+	assert.Equal(t, req.Start, res.Start)
+	assert.True(t, res.End.Sub(res.Start) >= 5*time.Millisecond)
+}
+
+func TestRecordingHandler_PassesThroughOptionalInterfaces(t *testing.T) {
+	var flusher, hijacker, pusher bool
+	handler := NewRecordingHandler(NewDiagram(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, flusher = w.(http.Flusher)
+		_, hijacker = w.(http.Hijacker)
+		_, pusher = w.(http.Pusher)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.True(t, flusher)
+	assert.False(t, hijacker)
+	assert.False(t, pusher)
+
+	handler.ServeHTTP(plainWriter{httptest.NewRecorder()}, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.False(t, flusher)
+}
+
+func TestRecordingHandler_AllowsHijacking(t *testing.T) {
+	diagram := NewDiagram()
+	handler := NewRecordingHandler(diagram, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+		rw.Flush()
+	}))
+	served := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+		close(served)
+	}))
+	defer server.Close()
+
+	res, err := http.Get(server.URL)
+	<-served
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusSwitchingProtocols, res.StatusCode)
+	assert.Equal(t, http.StatusSwitchingProtocols, diagram.Events[1].(HttpResponse).Value.StatusCode)
+}
+
+// plainWriter hides the optional interfaces of the wrapped writer
+type plainWriter struct {
+	w http.ResponseWriter
+}
+
+func (r plainWriter) Header() http.Header { return r.w.Header() }
+
+func (r plainWriter) Write(b []byte) (int, error) { return r.w.Write(b) }
+
+func (r plainWriter) WriteHeader(status int) { r.w.WriteHeader(status) }
@@ -0,0 +1,157 @@
+package sequence
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OTLP JSON types, see https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+type (
+	otlpTrace struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+		// InstrumentationLibrarySpans is the name used by OTLP before v0.15
+		InstrumentationLibrarySpans []otlpScopeSpans `json:"instrumentationLibrarySpans"`
+	}
+
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+
+	otlpScopeSpans struct {
+		Spans []otlpSpan `json:"spans"`
+	}
+
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId"`
+		Name              string          `json:"name"`
+		Kind              json.RawMessage `json:"kind"`
+		StartTimeUnixNano json.RawMessage `json:"startTimeUnixNano"`
+		EndTimeUnixNano   json.RawMessage `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes"`
+	}
+
+	otlpAttribute struct {
+		Key   string                     `json:"key"`
+		Value map[string]json.RawMessage `json:"value"`
+	}
+)
+
+// FromOTLP reads an OTLP JSON trace export and returns a diagram of the calls between services. Client
+// and server spans are paired into requests and responses between their service.name participants.
+// The export may hold several requests one after another, as the collector's file exporter writes one
+// per line, and the spans of all of them are read
+func FromOTLP(r io.Reader) (*Diagram, error) {
+	var trace otlpTrace
+	decoder := json.NewDecoder(r)
+	for batch := 1; ; batch++ {
+		var request otlpTrace
+		err := decoder.Decode(&request)
+		if err == io.EOF && batch > 1 {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid OTLP trace: batch %d: %v", batch, err)
+		}
+		trace.ResourceSpans = append(trace.ResourceSpans, request.ResourceSpans...)
+	}
+
+	var spans []span
+	for _, resourceSpans := range trace.ResourceSpans {
+		service := otlpAttributes(resourceSpans.Resource.Attributes)["service.name"]
+		for _, scopeSpans := range append(resourceSpans.ScopeSpans, resourceSpans.InstrumentationLibrarySpans...) {
+			for _, s := range scopeSpans.Spans {
+				converted, err := s.toSpan(service)
+				if err != nil {
+					return nil, err
+				}
+				spans = append(spans, converted)
+			}
+		}
+	}
+	if len(spans) == 0 {
+		return nil, fmt.Errorf("OTLP trace has no spans")
+	}
+	return diagramFromSpans(spans), nil
+}
+
+func (r otlpSpan) toSpan(service string) (span, error) {
+	start, err := otlpTime(r.StartTimeUnixNano)
+	if err != nil {
+		return span{}, fmt.Errorf("span %s: invalid startTimeUnixNano: %v", r.SpanID, err)
+	}
+	end, err := otlpTime(r.EndTimeUnixNano)
+	if err != nil {
+		return span{}, fmt.Errorf("span %s: invalid endTimeUnixNano: %v", r.SpanID, err)
+	}
+	attributes := otlpAttributes(r.Attributes)
+	if s := attributes["service.name"]; s != "" {
+		service = s
+	}
+	return span{
+		traceID:    r.TraceID,
+		spanID:     r.SpanID,
+		parentID:   r.ParentSpanID,
+		service:    service,
+		name:       r.Name,
+		kind:       otlpKind(r.Kind),
+		start:      start,
+		end:        end,
+		attributes: attributes,
+	}, nil
+}
+
+// otlpKind accepts both the enum number required by OTLP JSON and the enum name some exporters write
+func otlpKind(raw json.RawMessage) spanKind {
+	switch strings.Trim(string(raw), `"`) {
+	case "2", "SPAN_KIND_SERVER":
+		return spanKindServer
+	case "3", "SPAN_KIND_CLIENT":
+		return spanKindClient
+	case "4", "SPAN_KIND_PRODUCER":
+		return spanKindProducer
+	case "5", "SPAN_KIND_CONSUMER":
+		return spanKindConsumer
+	default:
+		return spanKindInternal
+	}
+}
+
+// otlpTime accepts nanoseconds since the epoch as a JSON string or number
+func otlpTime(raw json.RawMessage) (time.Time, error) {
+	value := strings.Trim(string(raw), `"`)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	nanos, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, nanos).UTC(), nil
+}
+
+// otlpAttributes flattens attribute values to strings
+func otlpAttributes(attributes []otlpAttribute) map[string]string {
+	flattened := map[string]string{}
+	for _, a := range attributes {
+		for _, raw := range a.Value {
+			var s string
+			if err := json.Unmarshal(raw, &s); err == nil {
+				flattened[a.Key] = s
+			} else {
+				flattened[a.Key] = string(raw)
+			}
+		}
+	}
+	return flattened
+}
@@ -0,0 +1,128 @@
+package sequence
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+const otlpFixture = `{
+  "resourceSpans": [
+    {
+      "resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "payments"}}]},
+      "scopeSpans": [{"spans": [
+        {
+          "traceId": "5b8efff798038103d269b633813fc60c", "spanId": "0000000000000003", "parentSpanId": "0000000000000002",
+          "name": "POST /charge", "kind": 2,
+          "startTimeUnixNano": "1544712660300000000", "endTimeUnixNano": "1544712660700000000",
+          "attributes": [{"key": "http.status_code", "value": {"intValue": "201"}}]
+        }
+      ]}]
+    },
+    {
+      "resource": {"attributes": [{"key": "service.name", "value": {"stringValue": "frontend"}}]},
+      "instrumentationLibrarySpans": [{"spans": [
+        {
+          "traceId": "5b8efff798038103d269b633813fc60c", "spanId": "0000000000000001",
+          "name": "GET /checkout", "kind": "SPAN_KIND_SERVER",
+          "startTimeUnixNano": 1544712660000000000, "endTimeUnixNano": 1544712661000000000,
+          "attributes": [
+            {"key": "http.method", "value": {"stringValue": "GET"}},
+            {"key": "http.target", "value": {"stringValue": "/checkout"}},
+            {"key": "http.status_code", "value": {"intValue": 200}}
+          ]
+        },
+        {
+          "traceId": "5b8efff798038103d269b633813fc60c", "spanId": "0000000000000002", "parentSpanId": "0000000000000001",
+          "name": "HTTP POST", "kind": 3,
+          "startTimeUnixNano": "1544712660200000000", "endTimeUnixNano": "1544712660800000000",
+          "attributes": [
+            {"key": "http.method", "value": {"stringValue": "POST"}},
+            {"key": "http.url", "value": {"stringValue": "http://payments/charge"}}
+          ]
+        },
+        {
+          "traceId": "5b8efff798038103d269b633813fc60c", "spanId": "0000000000000004", "parentSpanId": "0000000000000001",
+          "name": "render", "kind": 1,
+          "startTimeUnixNano": "1544712660850000000", "endTimeUnixNano": "1544712660900000000"
+        },
+        {
+          "traceId": "5b8efff798038103d269b633813fc60c", "spanId": "0000000000000005", "parentSpanId": "0000000000000001",
+          "name": "SELECT", "kind": 3,
+          "startTimeUnixNano": "1544712660100000000", "endTimeUnixNano": "1544712660150000000",
+          "attributes": [{"key": "db.system", "value": {"stringValue": "postgresql"}}]
+        }
+      ]}]
+    }
+  ]
+}`
+
+func TestFromOTLP(t *testing.T) {
+	diagram, err := FromOTLP(strings.NewReader(otlpFixture))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "GET /checkout", diagram.Title)
+	assert.Equal(t, "5b8efff798038103d269b633813fc60c", diagram.SubTitle)
+	var rows []string
+	for _, e := range diagram.Events {
+		rows = append(rows, e.From()+" -> "+e.To()+": "+e.Label())
+	}
+	assert.Equal(t, []string{
+		"client -> frontend: GET /checkout",
+		"frontend -> postgresql: SELECT",
+		"postgresql -> frontend: SELECT",
+		"frontend -> payments: POST http://payments/charge",
+		"payments -> frontend: 201",
+		"frontend -> client: 200",
+	}, rows)
+}
+
+func TestFromOTLP_ReadsEveryLineOfFileExport(t *testing.T) {
+	var trace struct {
+		ResourceSpans []json.RawMessage `json:"resourceSpans"`
+	}
+	assert.Nil(t, json.Unmarshal([]byte(otlpFixture), &trace))
+	var lines []string
+	for _, resourceSpans := range trace.ResourceSpans {
+		line, _ := json.Marshal(map[string]interface{}{"resourceSpans": []json.RawMessage{resourceSpans}})
+		lines = append(lines, string(line))
+	}
+	export := strings.Join(lines, "\n") + "\n"
+
+	diagram, err := FromOTLP(strings.NewReader(export))
+
+	assert.Nil(t, err)
+	assert.Len(t, lines, 2)
+	assert.Len(t, diagram.Events, 6)
+	assert.Equal(t, "payments", diagram.Events[3].To())
+}
+
+func TestFromOTLP_ErrorIfInvalidBatch(t *testing.T) {
+	_, err := FromOTLP(strings.NewReader(`{"resourceSpans": []}` + "\n{"))
+
+	assert.EqualError(t, err, "invalid OTLP trace: batch 2: unexpected EOF")
+}
+
+func TestFromOTLP_SetsStatusFromFinalResponse(t *testing.T) {
+	diagram, _ := FromOTLP(strings.NewReader(otlpFixture))
+
+	model, err := diagram.BuildModel()
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, model.StatusCode)
+	assert.Contains(t, model.LogEntries[0].Header, "GET /checkout\ntrace 5b8efff798038103d269b633813fc60c span 0000000000000001\nstarted 2018-12-13T14:51:00Z")
+	assert.Contains(t, model.LogEntries[0].Body, "http.target: /checkout\n")
+}
+
+func TestFromOTLP_ErrorIfNoSpans(t *testing.T) {
+	_, err := FromOTLP(strings.NewReader(`{"resourceSpans": []}`))
+
+	assert.EqualError(t, err, "OTLP trace has no spans")
+}
+
+func TestFromOTLP_ErrorIfInvalidTime(t *testing.T) {
+	_, err := FromOTLP(strings.NewReader(`{"resourceSpans": [{"scopeSpans": [{"spans": [{"spanId": "1", "startTimeUnixNano": "abc"}]}]}]}`))
+
+	assert.EqualError(t, err, `span 1: invalid startTimeUnixNano: strconv.ParseInt: parsing "abc": invalid syntax`)
+}
@@ -0,0 +1,122 @@
+package sequence
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ParticipantKind describes what a participant is. Renderers draw kinds their syntax supports, such as
+// PlantUML's database and queue shapes
+type ParticipantKind string
+
+const (
+	ParticipantActor    ParticipantKind = "actor"
+	ParticipantService  ParticipantKind = "service"
+	ParticipantDatabase ParticipantKind = "database"
+	ParticipantQueue    ParticipantKind = "queue"
+	ParticipantExternal ParticipantKind = "external"
+)
+
+// Participant declares how a participant of a diagram is drawn. Declared participants are drawn in the
+// order they were added, before participants that are only named by events
+type Participant struct {
+	// ID is the name events use as their Source or Target
+	ID string `json:"id"`
+	// Name is the label drawn for the participant. Defaults to ID
+	Name string          `json:"name,omitempty"`
+	Kind ParticipantKind `json:"kind,omitempty"`
+	// Color fills the participant, as a CSS color name or hex code such as #a3d2ff
+	Color string `json:"color,omitempty"`
+}
+
+// participantColor matches the CSS color names and hex codes a participant can be filled with
+var participantColor = regexp.MustCompile(`^([a-zA-Z]+|#([0-9a-fA-F]{3,4}|[0-9a-fA-F]{6}|[0-9a-fA-F]{8}))$`)
+
+// AddParticipant declares how a participant is drawn, replacing any earlier declaration with the same ID
+func (r *Diagram) AddParticipant(participant Participant) *Diagram {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, declared := range r.Participants {
+		if declared.ID == participant.ID {
+			r.Participants[i] = participant
+			return r
+		}
+	}
+	r.Participants = append(r.Participants, participant)
+	return r
+}
+
+// participantAliases assigns each participant a unique identifier that is safe to use in DSLs
+// which do not allow characters such as ':' or '.' in participant names
+type participantAliases struct {
+	names    []string
+	aliases  map[string]string
+	declared map[string]Participant
+}
+
+// alias returns the identifier for a participant, registering it on first use
+func (r *participantAliases) alias(name string) string {
+	if r.aliases == nil {
+		r.aliases = map[string]string{}
+	}
+	if alias, ok := r.aliases[name]; ok {
+		return alias
+	}
+
+	alias := identifier(name)
+	for n := 2; r.taken(alias); n++ {
+		alias = fmt.Sprintf("%s_%d", identifier(name), n)
+	}
+	r.aliases[name] = alias
+	r.names = append(r.names, name)
+	return alias
+}
+
+// declare registers a participant declared by the diagram
+func (r *participantAliases) declare(participant Participant) {
+	if r.declared == nil {
+		r.declared = map[string]Participant{}
+	}
+	r.alias(participant.ID)
+	r.declared[participant.ID] = participant
+}
+
+func (r *participantAliases) isDeclared(name string) bool {
+	_, ok := r.declared[name]
+	return ok
+}
+
+// participant returns the declaration of a participant, with its name defaulted to its ID
+func (r *participantAliases) participant(name string) Participant {
+	participant, ok := r.declared[name]
+	if !ok {
+		participant = Participant{ID: name}
+	}
+	if participant.Name == "" {
+		participant.Name = participant.ID
+	}
+	return participant
+}
+
+func (r *participantAliases) taken(alias string) bool {
+	for _, a := range r.aliases {
+		if a == alias {
+			return true
+		}
+	}
+	return false
+}
+
+func identifier(name string) string {
+	id := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+	if id == "" {
+		return "_"
+	}
+	return id
+}
@@ -0,0 +1,143 @@
+package sequence
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDiagram_RenderWebSequenceDSL_DeclaresParticipants(t *testing.T) {
+	dsl, err := aDiagramWithParticipants().RenderWebSequenceDSL()
+
+	assert.Nil(t, err)
+	assert.Equal(t, `participant "User" as user
+participant "Cart API" as example_com_443
+participant db
+participant app
+user->app: (1) GET /cart
+app->example_com_443: (2) GET /items
+example_com_443->>app: (3) 200
+app->>user: (4) 200
+`, dsl)
+}
+
+func TestDiagram_RenderWebSequenceDSL_AliasesUndeclaredParticipants(t *testing.T) {
+	dsl, err := NewDiagram().
+		AddMessageRequest(MessageRequest{Source: "app", Target: "cart-api:8080", Header: "GET /cart"}).
+		AddMessageResponse(MessageResponse{Source: "cart-api:8080", Target: "app", Header: "200"}).
+		RenderWebSequenceDSL()
+
+	assert.Nil(t, err)
+	assert.Equal(t, `participant app
+participant "cart-api:8080" as cart_api_8080
+app->cart_api_8080: (1) GET /cart
+cart_api_8080->>app: (2) 200
+`, dsl)
+}
+
+func TestDiagram_RenderMermaid_DeclaresParticipants(t *testing.T) {
+	mermaid, err := aDiagramWithParticipants().RenderMermaid()
+
+	assert.Nil(t, err)
+	assert.Equal(t, `sequenceDiagram
+    actor user as User
+    box #a3d2ff
+    participant example_com_443 as Cart API
+    end
+    participant db
+    participant app
+    user->>app: (1) GET /cart
+    app->>example_com_443: (2) GET /items
+    example_com_443-->>app: (3) 200
+    app-->>user: (4) 200
+`, mermaid)
+}
+
+func TestDiagram_RenderPlantUML_DeclaresParticipants(t *testing.T) {
+	plantUML, err := aDiagramWithParticipants().RenderPlantUML()
+
+	assert.Nil(t, err)
+	assert.Equal(t, `@startuml
+actor "User" as user
+boundary "Cart API" as example_com_443 #a3d2ff
+database db
+participant app
+user -> app : (1) GET /cart
+app -> example_com_443 : (2) GET /items
+example_com_443 --> app : (3) 200
+app --> user : (4) 200
+@enduml
+`, plantUML)
+}
+
+func TestDiagram_RenderSVG_DeclaresParticipants(t *testing.T) {
+	svg, err := aDiagramWithParticipants().RenderSVG()
+
+	assert.Nil(t, err)
+	assert.Contains(t, svg, `<rect class="participant actor" x="20" y="20" width="52" height="36" rx="18" fill="white" stroke="black"/>`)
+	assert.Contains(t, svg, `<rect class="participant external" x="112" y="20" width="84" height="36" stroke-dasharray="4,2" fill="#a3d2ff" stroke="black"/>`)
+	assert.Contains(t, svg, `<path class="participant database"`)
+	assert.Contains(t, svg, ">Cart API</text>")
+	assert.NotContains(t, svg, ">example.com:443</text>")
+}
+
+func TestDiagram_AddParticipant_ReplacesDeclarationWithTheSameID(t *testing.T) {
+	diagram := NewDiagram().
+		AddParticipant(Participant{ID: "a", Name: "A"}).
+		AddParticipant(Participant{ID: "b"}).
+		AddParticipant(Participant{ID: "a", Name: "Alice"})
+
+	assert.Equal(t, []Participant{{ID: "a", Name: "Alice"}, {ID: "b"}}, diagram.Participants)
+}
+
+func TestDiagram_RenderMermaid_ErrorIfParticipantHasNoID(t *testing.T) {
+	_, err := aDiagram().AddParticipant(Participant{Name: "nobody"}).RenderMermaid()
+
+	assert.EqualError(t, err, "participant 1 has no id")
+}
+
+func TestDiagram_RenderMermaid_ErrorIfParticipantColorIsInvalid(t *testing.T) {
+	_, err := aDiagram().AddParticipant(Participant{ID: "db", Color: "red\n    participant injected"}).RenderMermaid()
+
+	assert.EqualError(t, err, `participant 1 has invalid color "red\n    participant injected", use a CSS color name or hex code`)
+}
+
+func TestDiagram_RenderMermaid_AcceptsColorNamesAndHexCodes(t *testing.T) {
+	for _, color := range []string{"Aqua", "#fff", "#a3d2ff", "#a3d2ff80"} {
+		_, err := aDiagram().AddParticipant(Participant{ID: "db", Color: color}).RenderMermaid()
+
+		assert.Nil(t, err, color)
+	}
+}
+
+func TestDiagram_MarshalJSON_RoundTripsParticipants(t *testing.T) {
+	data, err := json.Marshal(aDiagramWithParticipants())
+	assert.Nil(t, err)
+
+	var diagram Diagram
+	err = json.Unmarshal(data, &diagram)
+
+	assert.Nil(t, err)
+	assert.Equal(t, aDiagramWithParticipants().Participants, diagram.Participants)
+}
+
+func TestDocument_BuildModel_UsesParticipantsOfDocumentDiagrams(t *testing.T) {
+	model, err := NewDocument().
+		AddRedaction(DefaultRedaction()).
+		AddDiagram(aDiagramWithParticipants()).
+		BuildModel()
+
+	assert.Nil(t, err)
+	assert.Contains(t, model.Diagrams[0].WebSequenceDSL, `participant "Cart API" as example_com_443`+"\n")
+}
+
+func aDiagramWithParticipants() *Diagram {
+	return NewDiagram().
+		AddParticipant(Participant{ID: "user", Name: "User", Kind: ParticipantActor}).
+		AddParticipant(Participant{ID: "example.com:443", Name: "Cart API", Kind: ParticipantExternal, Color: "#a3d2ff"}).
+		AddParticipant(Participant{ID: "db", Kind: ParticipantDatabase}).
+		AddMessageRequest(MessageRequest{Source: "user", Target: "app", Header: "GET /cart"}).
+		AddMessageRequest(MessageRequest{Source: "app", Target: "example.com:443", Header: "GET /items"}).
+		AddMessageResponse(MessageResponse{Source: "example.com:443", Target: "app", Header: "200"}).
+		AddMessageResponse(MessageResponse{Source: "app", Target: "user", Header: "200"})
+}
@@ -0,0 +1,129 @@
+package sequence
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// PlantUMLDiagram builds a PlantUML sequence diagram. Participants are declared in the order they first
+// appear so the output is deterministic and can be checked in
+type PlantUMLDiagram struct {
+	data         bytes.Buffer
+	count        int
+	participants participantAliases
+	title        string
+	subTitle     string
+	status       int
+}
+
+func (r *PlantUMLDiagram) AddParticipant(participant Participant) {
+	r.participants.declare(participant)
+}
+
+func (r *PlantUMLDiagram) AddRequestRow(source, target, description string) {
+	r.addRow("->", source, target, description)
+}
+
+func (r *PlantUMLDiagram) AddResponseRow(source, target, description string) {
+	r.addRow("-->", source, target, description)
+}
+
+func (r *PlantUMLDiagram) addRow(operation, source, target, description string) {
+	r.count += 1
+	r.data.WriteString(fmt.Sprintf("%s %s %s : (%d) %s\n",
+		r.participants.alias(source),
+		operation,
+		r.participants.alias(target),
+		r.count,
+		escapePlantUML(description)))
+}
+
+func (r *PlantUMLDiagram) AddNote(position NotePosition, participants []string, text string) {
+	aliases := make([]string, len(participants))
+	for i, name := range participants {
+		aliases[i] = r.participants.alias(name)
+	}
+	r.data.WriteString(fmt.Sprintf("note %s %s : %s\n", position, strings.Join(aliases, ", "), escapePlantUML(text)))
+}
+
+func (r *PlantUMLDiagram) AddDivider(text string) {
+	r.data.WriteString(fmt.Sprintf("== %s ==\n", escapePlantUML(text)))
+}
+
+func (r *PlantUMLDiagram) AddGroupStart(kind GroupKind, label string) {
+	r.data.WriteString(fmt.Sprintf("%s\n", groupLine(string(kind), escapePlantUML(label))))
+}
+
+// AddGroupBranch starts another branch with else, which PlantUML uses for every kind of group
+func (r *PlantUMLDiagram) AddGroupBranch(kind GroupKind, label string) {
+	r.data.WriteString(fmt.Sprintf("%s\n", groupLine("else", escapePlantUML(label))))
+}
+
+func (r *PlantUMLDiagram) AddGroupEnd(kind GroupKind) {
+	r.data.WriteString("end\n")
+}
+
+func (r *PlantUMLDiagram) ToString() string {
+	var out bytes.Buffer
+	out.WriteString("@startuml\n")
+	if r.title != "" {
+		out.WriteString(fmt.Sprintf("title %s\n", escapePlantUML(r.title)))
+	}
+	if r.status > 0 {
+		out.WriteString(fmt.Sprintf("header %d\n", r.status))
+	}
+	if r.subTitle != "" {
+		out.WriteString(fmt.Sprintf("caption %s\n", escapePlantUML(r.subTitle)))
+	}
+	for _, name := range r.participants.names {
+		participant := r.participants.participant(name)
+		alias := r.participants.alias(name)
+		line := fmt.Sprintf("%s %s", plantUMLKeyword(participant.Kind), alias)
+		if alias != participant.Name {
+			line = fmt.Sprintf("%s \"%s\" as %s", plantUMLKeyword(participant.Kind), strings.Replace(escapePlantUML(participant.Name), `"`, `'`, -1), alias)
+		}
+		if participant.Color != "" {
+			line += " #" + strings.TrimPrefix(participant.Color, "#")
+		}
+		out.WriteString(line + "\n")
+	}
+	out.Write(r.data.Bytes())
+	out.WriteString("@enduml\n")
+	return out.String()
+}
+
+// RenderPlantUML renders the diagram as a PlantUML @startuml ... @enduml block
+func (r *Diagram) RenderPlantUML() (string, error) {
+	status, err := r.responseStatus()
+	if err != nil {
+		return "", err
+	}
+
+	plantUML := &PlantUMLDiagram{title: r.Title, subTitle: r.SubTitle, status: status}
+	if err := r.writeDSL(plantUML); err != nil {
+		return "", err
+	}
+	return plantUML.ToString(), nil
+}
+
+// RenderPlantUML renders every diagram in the document as a PlantUML block, separated by a blank line
+func (r *Document) RenderPlantUML() (string, error) {
+	return r.renderEach((*Diagram).RenderPlantUML)
+}
+
+// plantUMLKeyword returns the keyword that declares a participant of the given kind
+func plantUMLKeyword(kind ParticipantKind) string {
+	switch kind {
+	case ParticipantActor, ParticipantDatabase, ParticipantQueue:
+		return string(kind)
+	case ParticipantExternal:
+		return "boundary"
+	}
+	return "participant"
+}
+
+// escapePlantUML keeps multi line text on a single PlantUML line using its \n escape
+func escapePlantUML(text string) string {
+	return strings.NewReplacer("\r\n", `\n`, "\n", `\n`).Replace(text)
+}
@@ -0,0 +1,67 @@
+package sequence
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPlantUMLDiagram_GeneratesDSL(t *testing.T) {
+	plantUML := PlantUMLDiagram{}
+	plantUML.AddRequestRow("A", "B", "request1")
+	plantUML.AddRequestRow("B", "C", "request2")
+	plantUML.AddResponseRow("C", "B", "response1")
+	plantUML.AddResponseRow("B", "A", "response2")
+
+	dsl := plantUML.ToString()
+
+	assert.Equal(t, `@startuml
+participant A
+participant B
+participant C
+A -> B : (1) request1
+B -> C : (2) request2
+C --> B : (3) response1
+B --> A : (4) response2
+@enduml
+`, dsl)
+}
+
+func TestDiagram_RenderPlantUML(t *testing.T) {
+	diagram := NewDiagram().
+		AddTitle("title").
+		AddSubTitle("line1\nline2").
+		AddHttpRequest(HttpRequest{Source: "app", Target: "example.com:443", Value: aRequest().Value}).
+		AddHttpResponse(HttpResponse{Source: "example.com:443", Target: "app", Value: aResponse().Value})
+
+	dsl, err := diagram.RenderPlantUML()
+
+	assert.Nil(t, err)
+	assert.Equal(t, `@startuml
+title title
+header 204
+caption line1\nline2
+participant app
+participant "example.com:443" as example_com_443
+app -> example_com_443 : (1) GET http://example.com/abcdef
+example_com_443 --> app : (2) 204
+@enduml
+`, dsl)
+}
+
+func TestDiagram_RenderPlantUML_ErrorIfResponseTypeNotFinalEvent(t *testing.T) {
+	_, err := aDiagram().AddHttpRequest(aRequest()).RenderPlantUML()
+
+	assert.EqualError(t, err, "final event should be a response type")
+}
+
+func TestDocument_RenderPlantUML(t *testing.T) {
+	document := NewDocument().
+		AddDiagram(aDiagram()).
+		AddDiagram(aDiagram())
+
+	dsl, err := document.RenderPlantUML()
+
+	assert.Nil(t, err)
+	first, _ := aDiagram().RenderPlantUML()
+	assert.Equal(t, first+"\n"+first, dsl)
+}
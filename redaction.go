@@ -0,0 +1,443 @@
+package sequence
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// defaultMask replaces redacted values unless Redaction.Mask is set
+const defaultMask = "[REDACTED]"
+
+// Redaction masks sensitive values in captured traffic. It is applied when a Diagram or Document is
+// rendered or exported, so the recorded events keep their original values. Encoded HTTP bodies, such as
+// gzip responses, are decoded before they are redacted. Custom event types are rendered unchanged
+type Redaction struct {
+	// Headers lists the names of headers whose values are masked, matched case insensitively.
+	// Lines of message headers in the form "Name: value" are matched too
+	Headers []string
+	// Patterns are matched against bodies, message headers and the query of request URLs. When a pattern
+	// has capture groups only the groups are masked, otherwise the whole match is
+	Patterns []*regexp.Regexp
+	// JSONPaths select fields of JSON bodies to mask, such as $.password, $.users[*].token or $..secret.
+	// A body in which a field is masked is encoded again with the keys of every object sorted
+	JSONPaths []string
+	// Mask replaces each redacted value. Defaults to [REDACTED]
+	Mask string
+}
+
+type jsonPathSegment struct {
+	key       string
+	index     int
+	isIndex   bool
+	wildcard  bool
+	recursive bool
+}
+
+func NewRedaction() *Redaction {
+	return &Redaction{}
+}
+
+// DefaultRedaction masks the headers that carry credentials
+func DefaultRedaction() *Redaction {
+	return NewRedaction().RedactHeaders("Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key")
+}
+
+func (r *Redaction) RedactHeaders(names ...string) *Redaction {
+	r.Headers = append(r.Headers, names...)
+	return r
+}
+
+func (r *Redaction) RedactPatterns(patterns ...*regexp.Regexp) *Redaction {
+	r.Patterns = append(r.Patterns, patterns...)
+	return r
+}
+
+func (r *Redaction) RedactJSONPaths(paths ...string) *Redaction {
+	r.JSONPaths = append(r.JSONPaths, paths...)
+	return r
+}
+
+func (r *Redaction) WithMask(mask string) *Redaction {
+	r.Mask = mask
+	return r
+}
+
+// AddRedaction sets the redaction applied when the diagram is rendered or exported
+func (r *Diagram) AddRedaction(redaction *Redaction) *Diagram {
+	r.Redaction = redaction
+	return r
+}
+
+// AddRedaction sets the redaction applied to every diagram in the document that has no redaction of its own
+func (r *Document) AddRedaction(redaction *Redaction) *Document {
+	r.Redaction = redaction
+	return r
+}
+
+// events returns the events of the diagram with its participant resolver and redaction applied. Participants
+// are resolved first, so rules can match headers that are redacted
+func (r *Diagram) events() ([]Event, error) {
+	_, resolved := r.recorded()
+	if r.Resolver != nil {
+		resolved = r.Resolver.events(resolved)
+	}
+	if r.Redaction == nil {
+		return resolved, nil
+	}
+	events := make([]Event, len(resolved))
+	for i, event := range resolved {
+		redacted, err := r.Redaction.event(event)
+		if err != nil {
+			return nil, fmt.Errorf("event %d: %v", i+1, err)
+		}
+		events[i] = redacted
+	}
+	return events, nil
+}
+
+func (r *Redaction) event(event Event) (Event, error) {
+	switch v := event.(type) {
+	case HttpRequest:
+		if v.Value == nil {
+			return v, nil
+		}
+		body, rest, err := readBody(v.Value.Body)
+		if err != nil {
+			return nil, err
+		}
+		v.Value.Body = rest
+		req := v.Value.Clone(v.Value.Context())
+		req.Header = r.header(req.Header)
+		r.url(req)
+		if body != nil {
+			var redacted []byte
+			redacted, req.Header, err = r.httpBody(body, req.Header)
+			if err != nil {
+				return nil, err
+			}
+			req.Body = ioutil.NopCloser(bytes.NewReader(redacted))
+			if len(redacted) != len(body) {
+				req.ContentLength = int64(len(redacted))
+			}
+		}
+		v.Value = req
+		return v, nil
+	case HttpResponse:
+		if v.Value == nil {
+			return v, nil
+		}
+		body, rest, err := readBody(v.Value.Body)
+		if err != nil {
+			return nil, err
+		}
+		v.Value.Body = rest
+		res := *v.Value
+		res.Header = r.header(res.Header)
+		if body != nil {
+			var redacted []byte
+			redacted, res.Header, err = r.httpBody(body, res.Header)
+			if err != nil {
+				return nil, err
+			}
+			res.Body = ioutil.NopCloser(bytes.NewReader(redacted))
+			if len(redacted) != len(body) {
+				res.ContentLength = int64(len(redacted))
+			}
+		}
+		v.Value = &res
+		return v, nil
+	case MessageRequest:
+		body, err := r.body([]byte(v.Body))
+		v.Header, v.Body = r.messageHeader(v.Header), string(body)
+		return v, err
+	case MessageResponse:
+		body, err := r.body([]byte(v.Body))
+		v.Header, v.Body = r.messageHeader(v.Header), string(body)
+		return v, err
+	case SpanRequest:
+		v.Attributes = r.attributes(v.Attributes)
+		return v, nil
+	}
+	return event, nil
+}
+
+func (r *Redaction) mask() string {
+	if r.Mask == "" {
+		return defaultMask
+	}
+	return r.Mask
+}
+
+func (r *Redaction) isRedactedHeader(name string) bool {
+	for _, redacted := range r.Headers {
+		if strings.EqualFold(strings.TrimSpace(name), redacted) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Redaction) header(header http.Header) http.Header {
+	if header == nil || len(r.Headers) == 0 {
+		return header
+	}
+	redacted := header.Clone()
+	for name, values := range redacted {
+		if r.isRedactedHeader(name) {
+			masked := make([]string, len(values))
+			for i := range masked {
+				masked[i] = r.mask()
+			}
+			redacted[name] = masked
+		}
+	}
+	return redacted
+}
+
+// url applies the patterns to the query of the request, which often carries API keys and tokens. The
+// request URI of a server request is rebuilt from the redacted URL
+func (r *Redaction) url(req *http.Request) {
+	if req.URL == nil || req.URL.RawQuery == "" || len(r.Patterns) == 0 {
+		return
+	}
+	query := string(r.patterns([]byte(req.URL.RawQuery)))
+	if query == req.URL.RawQuery {
+		return
+	}
+	req.URL.RawQuery = query
+	if req.RequestURI != "" {
+		req.RequestURI = req.URL.RequestURI()
+	}
+}
+
+// messageHeader masks "Name: value" lines of redacted headers and then applies the patterns
+func (r *Redaction) messageHeader(header string) string {
+	lines := strings.Split(header, "\n")
+	for i, line := range lines {
+		if colon := strings.Index(line, ":"); colon > 0 && r.isRedactedHeader(line[:colon]) {
+			lines[i] = line[:colon] + ": " + r.mask()
+		}
+	}
+	return string(r.patterns([]byte(strings.Join(lines, "\n"))))
+}
+
+// attributes masks span attributes that record redacted headers, such as http.request.header.authorization
+func (r *Redaction) attributes(attributes map[string]string) map[string]string {
+	if attributes == nil {
+		return nil
+	}
+	redacted := map[string]string{}
+	for key, value := range attributes {
+		header := key[strings.LastIndex(key, ".")+1:]
+		if strings.Contains(key, ".header.") && r.isRedactedHeader(strings.Replace(header, "_", "-", -1)) {
+			value = r.mask()
+		}
+		redacted[key] = string(r.patterns([]byte(value)))
+	}
+	return redacted
+}
+
+// httpBody redacts the body of a request or response. A body sent with a Content-Encoding is decoded
+// first, so the rules see its content, and the redacted copy is returned without the encoding. A body
+// that cannot be decoded is masked whole, since the rules cannot be applied to it
+func (r *Redaction) httpBody(body []byte, header http.Header) ([]byte, http.Header, error) {
+	if len(r.Patterns) == 0 && len(r.JSONPaths) == 0 {
+		return body, header, nil
+	}
+	if encoding := header.Get("Content-Encoding"); encoding != "" {
+		decoded, err := decodeContent(body, encoding)
+		header = header.Clone()
+		header.Del("Content-Encoding")
+		header.Del("Content-Length")
+		if err != nil {
+			return []byte(r.mask()), header, nil
+		}
+		body = decoded
+	}
+	redacted, err := r.body(body)
+	return redacted, header, err
+}
+
+func (r *Redaction) body(body []byte) ([]byte, error) {
+	if len(body) == 0 {
+		return body, nil
+	}
+	body, err := r.jsonFields(body)
+	if err != nil {
+		return nil, err
+	}
+	return r.patterns(body), nil
+}
+
+func (r *Redaction) patterns(text []byte) []byte {
+	for _, pattern := range r.Patterns {
+		text = maskPattern(pattern, text, r.mask())
+	}
+	return text
+}
+
+// maskPattern replaces the capture groups of each match, or the whole match when the pattern has no groups
+func maskPattern(pattern *regexp.Regexp, text []byte, mask string) []byte {
+	if pattern.NumSubexp() == 0 {
+		return pattern.ReplaceAllLiteral(text, []byte(mask))
+	}
+	var out bytes.Buffer
+	last := 0
+	for _, match := range pattern.FindAllSubmatchIndex(text, -1) {
+		for group := 1; 2*group < len(match); group++ {
+			start, end := match[2*group], match[2*group+1]
+			if start < last {
+				continue
+			}
+			out.Write(text[last:start])
+			out.WriteString(mask)
+			last = end
+		}
+	}
+	out.Write(text[last:])
+	return out.Bytes()
+}
+
+// jsonFields masks the fields selected by the JSON paths. Bodies that are not JSON, or in which no path
+// matches, are returned unchanged. A body that is masked is encoded again, compact and with the keys of
+// every object sorted
+func (r *Redaction) jsonFields(body []byte) ([]byte, error) {
+	if len(r.JSONPaths) == 0 {
+		return body, nil
+	}
+	var paths [][]jsonPathSegment
+	for _, path := range r.JSONPaths {
+		segments, err := parseJSONPath(path)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, segments)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return body, nil
+	}
+	changed := false
+	for _, segments := range paths {
+		var ok bool
+		document, ok = maskJSONPath(document, segments, r.mask())
+		changed = changed || ok
+	}
+	if !changed {
+		return body, nil
+	}
+
+	var out bytes.Buffer
+	encoder := json.NewEncoder(&out)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(document); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(out.Bytes(), []byte("\n")), nil
+}
+
+// parseJSONPath parses the subset of JSONPath made of child names, array indexes, wildcards and
+// recursive descent, for example $.users[0].password, $.users[*]['api-key'] or $..token
+func parseJSONPath(path string) ([]jsonPathSegment, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid JSON path %q: must start with $", path)
+	}
+	var segments []jsonPathSegment
+	rest := path[1:]
+	for rest != "" {
+		var segment jsonPathSegment
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			segment.recursive = true
+			rest = rest[2:]
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+		case strings.HasPrefix(rest, "["):
+		default:
+			return nil, fmt.Errorf("invalid JSON path %q: unexpected %q", path, rest)
+		}
+
+		if strings.HasPrefix(rest, "[") {
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSON path %q: unclosed [", path)
+			}
+			selector := rest[1:end]
+			rest = rest[end+1:]
+			switch {
+			case selector == "*":
+				segment.wildcard = true
+			case len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0]:
+				segment.key = selector[1 : len(selector)-1]
+			default:
+				index, err := strconv.Atoi(selector)
+				if err != nil {
+					return nil, fmt.Errorf("invalid JSON path %q: invalid index %q", path, selector)
+				}
+				segment.index, segment.isIndex = index, true
+			}
+		} else {
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			segment.key = rest[:end]
+			rest = rest[end:]
+			if segment.key == "" {
+				return nil, fmt.Errorf("invalid JSON path %q: empty name", path)
+			}
+			segment.wildcard = segment.key == "*"
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+// maskJSONPath returns node with the values selected by segments replaced by mask, and whether any were.
+// A recursive segment keeps descending into the children it matches, so nested matches are masked too
+func maskJSONPath(node interface{}, segments []jsonPathSegment, mask string) (interface{}, bool) {
+	if len(segments) == 0 {
+		return mask, true
+	}
+	segment, rest := segments[0], segments[1:]
+
+	changed := false
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for key, child := range n {
+			if segment.wildcard || (!segment.isIndex && key == segment.key) {
+				var ok bool
+				child, ok = maskJSONPath(child, rest, mask)
+				n[key], changed = child, changed || ok
+			}
+			if segment.recursive {
+				var ok bool
+				n[key], ok = maskJSONPath(child, segments, mask)
+				changed = changed || ok
+			}
+		}
+	case []interface{}:
+		for i, child := range n {
+			if segment.wildcard || (segment.isIndex && (i == segment.index || i == len(n)+segment.index)) {
+				var ok bool
+				child, ok = maskJSONPath(child, rest, mask)
+				n[i], changed = child, changed || ok
+			}
+			if segment.recursive {
+				var ok bool
+				n[i], ok = maskJSONPath(child, segments, mask)
+				changed = changed || ok
+			}
+		}
+	}
+	return node, changed
+}
@@ -0,0 +1,223 @@
+package sequence
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func aLoginDiagram() (*Diagram, *http.Request) {
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com/login", strings.NewReader(`{"user":"jan","password":"hunter2"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer abc")
+	res := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Set-Cookie": []string{"session=xyz"}, "Content-Type": []string{"text/plain"}},
+		Body:       ioutil.NopCloser(strings.NewReader("token=s3cr3t&expires=60")),
+	}
+	diagram := NewDiagram().
+		AddHttpRequest(HttpRequest{Source: "app", Target: "example.com", Value: req}).
+		AddHttpResponse(HttpResponse{Source: "example.com", Target: "app", Value: res})
+	return diagram, req
+}
+
+func TestDiagram_Redaction_MasksHeadersAndBodiesInHTML(t *testing.T) {
+	diagram, _ := aLoginDiagram()
+	diagram.AddRedaction(DefaultRedaction().
+		RedactJSONPaths("$.password").
+		RedactPatterns(regexp.MustCompile(`token=([^&]+)`)))
+
+	model, err := diagram.BuildModel()
+
+	assert.Nil(t, err)
+	assert.Contains(t, model.LogEntries[0].Header, "Authorization: [REDACTED]")
+	assert.NotContains(t, model.LogEntries[0].Header, "Bearer abc")
+	assert.Contains(t, model.LogEntries[1].Header, "Set-Cookie: [REDACTED]")
+	assert.Equal(t, "token=[REDACTED]&expires=60", model.LogEntries[1].Body)
+}
+
+func TestDiagram_Redaction_MasksURLQuery(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com/posts?token=s3cr3t&page=2", nil)
+	diagram := NewDiagram().
+		AddHttpRequest(HttpRequest{Source: "app", Target: "example.com", Value: req}).
+		AddHttpResponse(HttpResponse{Source: "example.com", Target: "app", Value: aResponse().Value}).
+		AddRedaction(NewRedaction().RedactPatterns(regexp.MustCompile(`token=(\w+)`)))
+
+	dsl, err := diagram.RenderWebSequenceDSL()
+	assert.Nil(t, err)
+	model, err := diagram.BuildModel()
+	assert.Nil(t, err)
+	archive, err := diagram.ToHAR()
+	assert.Nil(t, err)
+
+	assert.Contains(t, dsl, "GET http://example.com/posts?token=[REDACTED]&page=2")
+	assert.Contains(t, model.LogEntries[0].Header, "GET /posts?token=[REDACTED]&page=2 HTTP/1.1")
+	for _, out := range []string{dsl, model.LogEntries[0].Header, string(archive)} {
+		assert.NotContains(t, out, "s3cr3t")
+	}
+	assert.Equal(t, "http://example.com/posts?token=s3cr3t&page=2", req.URL.String())
+}
+
+func TestDiagram_Redaction_MasksJSONFields(t *testing.T) {
+	diagram, _ := aLoginDiagram()
+	diagram.AddRedaction(NewRedaction().RedactJSONPaths("$.password"))
+
+	events, err := diagram.events()
+
+	assert.Nil(t, err)
+	req := events[0].(HttpRequest).Value
+	body, _ := ioutil.ReadAll(req.Body)
+	assert.Equal(t, `{"password":"[REDACTED]","user":"jan"}`, string(body))
+	assert.Equal(t, int64(len(body)), req.ContentLength)
+}
+
+func TestDiagram_Redaction_DecodesBodiesBeforeRedacting(t *testing.T) {
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	gz.Write([]byte(`{"password":"hunter2","user":"jan"}`))
+	gz.Close()
+	res := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Encoding": []string{"gzip"}, "Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(bytes.NewReader(gzipped.Bytes())),
+	}
+	diagram := NewDiagram().
+		AddHttpRequest(aRequest()).
+		AddHttpResponse(HttpResponse{Value: res}).
+		AddRedaction(NewRedaction().RedactJSONPaths("$.password").RedactPatterns(regexp.MustCompile(`jan`)))
+
+	model, err := diagram.BuildModel()
+
+	assert.Nil(t, err)
+	assert.NotContains(t, model.LogEntries[1].Header, "Content-Encoding")
+	assert.Equal(t, "{\n    \"password\": \"[REDACTED]\",\n    \"user\": \"[REDACTED]\"\n}", model.LogEntries[1].Body)
+}
+
+func TestDiagram_Redaction_MasksBodiesThatCannotBeDecoded(t *testing.T) {
+	res := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Encoding": []string{"gzip"}},
+		Body:       ioutil.NopCloser(strings.NewReader(`{"password":"hunter2"}`)),
+	}
+	diagram := NewDiagram().
+		AddHttpRequest(aRequest()).
+		AddHttpResponse(HttpResponse{Value: res}).
+		AddRedaction(NewRedaction().RedactJSONPaths("$.password"))
+
+	model, err := diagram.BuildModel()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "[REDACTED]", model.LogEntries[1].Body)
+}
+
+func TestDiagram_Redaction_LeavesRecordedEventsUnchanged(t *testing.T) {
+	diagram, req := aLoginDiagram()
+	diagram.AddRedaction(DefaultRedaction().RedactJSONPaths("$.password"))
+
+	_, err := diagram.BuildModel()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Bearer abc", req.Header.Get("Authorization"))
+	body, _ := ioutil.ReadAll(req.Body)
+	assert.Equal(t, `{"user":"jan","password":"hunter2"}`, string(body))
+}
+
+func TestDocument_Redaction_AppliesToExports(t *testing.T) {
+	diagram, _ := aLoginDiagram()
+	document := NewDocument().AddDiagram(diagram).
+		AddRedaction(DefaultRedaction().RedactJSONPaths("$.password"))
+
+	recording, err := json.Marshal(document)
+	assert.Nil(t, err)
+	archive, err := document.ToHAR()
+	assert.Nil(t, err)
+	markdown, err := document.RenderMarkdown()
+	assert.Nil(t, err)
+
+	for _, out := range []string{string(recording), string(archive), markdown} {
+		assert.NotContains(t, out, "hunter2")
+		assert.NotContains(t, out, "Bearer abc")
+		assert.NotContains(t, out, "session=xyz")
+	}
+}
+
+func TestDocument_Redaction_DiagramRedactionTakesPrecedence(t *testing.T) {
+	diagram, _ := aLoginDiagram()
+	diagram.AddRedaction(NewRedaction())
+	document := NewDocument().AddDiagram(diagram).AddRedaction(DefaultRedaction())
+
+	markdown, err := document.RenderMarkdown()
+
+	assert.Nil(t, err)
+	assert.Contains(t, markdown, "Bearer abc")
+}
+
+func TestDiagram_Redaction_MasksMessagesInDSL(t *testing.T) {
+	diagram := NewDiagram().
+		AddMessageRequest(MessageRequest{Source: "app", Target: "db", Header: "connect password=secret"}).
+		AddMessageResponse(MessageResponse{Source: "db", Target: "app", Header: "ok"}).
+		AddRedaction(NewRedaction().WithMask("***").RedactPatterns(regexp.MustCompile(`password=(\S+)`)))
+
+	dsl, err := diagram.RenderWebSequenceDSL()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "app->db: (1) connect password=***\ndb->>app: (2) ok\n", dsl)
+}
+
+func TestRedaction_MessageHeaderLines(t *testing.T) {
+	redaction := NewRedaction().RedactHeaders("authorization")
+
+	assert.Equal(t, "GET /\nAuthorization: [REDACTED]\nAccept: */*", redaction.messageHeader("GET /\nAuthorization: Basic Zm9v\nAccept: */*"))
+}
+
+func TestRedaction_SpanHeaderAttributes(t *testing.T) {
+	redaction := DefaultRedaction()
+
+	redacted := redaction.attributes(map[string]string{"http.request.header.x_api_key": "k", "http.method": "GET"})
+
+	assert.Equal(t, map[string]string{"http.request.header.x_api_key": "[REDACTED]", "http.method": "GET"}, redacted)
+}
+
+func TestRedaction_JSONPaths(t *testing.T) {
+	tests := []struct {
+		path     string
+		body     string
+		expected string
+	}{
+		{path: "$.password", body: `{"password":"p","n":1.50}`, expected: `{"n":1.50,"password":"X"}`},
+		{path: "$.user.token", body: `{"user":{"token":"t","id":1}}`, expected: `{"user":{"id":1,"token":"X"}}`},
+		{path: "$.users[*].token", body: `{"users":[{"token":"a"},{"token":"b"}]}`, expected: `{"users":[{"token":"X"},{"token":"X"}]}`},
+		{path: "$.users[1].token", body: `{"users":[{"token":"a"},{"token":"b"}]}`, expected: `{"users":[{"token":"a"},{"token":"X"}]}`},
+		{path: "$..secret", body: `{"a":{"secret":1},"b":[{"secret":"<2>"}]}`, expected: `{"a":{"secret":"X"},"b":[{"secret":"X"}]}`},
+		{path: "$..a.b", body: `{"a":{"a":{"b":1}}}`, expected: `{"a":{"a":{"b":"X"}}}`},
+		{path: "$..a", body: `{"a":{"a":1}}`, expected: `{"a":"X"}`},
+		{path: "$..[0]", body: `[[1,2],3]`, expected: `["X",3]`},
+		{path: "$['api-key']", body: `{"api-key":"k"}`, expected: `{"api-key":"X"}`},
+		{path: "$.missing", body: `{"password": "p"}`, expected: `{"password": "p"}`},
+		{path: "$.password", body: `password=p`, expected: `password=p`},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			redaction := NewRedaction().WithMask("X").RedactJSONPaths(test.path)
+
+			body, err := redaction.body([]byte(test.body))
+
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, string(body))
+		})
+	}
+}
+
+func TestRedaction_ErrorIfInvalidJSONPath(t *testing.T) {
+	redaction := NewRedaction().RedactJSONPaths("password")
+
+	_, err := redaction.body([]byte(`{}`))
+
+	assert.EqualError(t, err, `invalid JSON path "password": must start with $`)
+}
@@ -0,0 +1,222 @@
+package sequence
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type (
+	// ReportWriter saves documents as HTML files in a directory and maintains an index.html that links
+	// to every report in it. Each report is described by its own .report.json file and the index is
+	// rebuilt from all of them on each write, so test packages run as parallel processes can write to
+	// the same directory
+	ReportWriter struct {
+		Dir string
+		// FullBodies stores the full body of truncated log entries next to each report, see Document.LimitBodySize
+		FullBodies bool
+		mu         sync.Mutex
+	}
+
+	ReportIndexEntry struct {
+		Name         string
+		File         string
+		Title        string
+		DiagramCount int
+		StatusCode   int
+		BadgeClass   string
+		// EmbeddedAssets records that the report inlines its assets, see Document.UseEmbeddedAssets
+		EmbeddedAssets bool `json:",omitempty"`
+	}
+
+	reportIndexModel struct {
+		Entries     []ReportIndexEntry
+		Stylesheets []HtmlAsset
+	}
+)
+
+func NewReportWriter(dir string) *ReportWriter {
+	return &ReportWriter{Dir: dir}
+}
+
+// WithFullBodies stores the full body of truncated log entries in a directory next to each report
+func (r *ReportWriter) WithFullBodies() *ReportWriter {
+	r.FullBodies = true
+	return r
+}
+
+// Write renders the document to a file named after name, typically t.Name(), adds it to the index
+// and returns the path of the written file
+func (r *ReportWriter) Write(name string, document *Document) (string, error) {
+	file := reportFileName(name)
+	if r.FullBodies && document.Sidecar == nil {
+		bodies := strings.TrimSuffix(file, filepath.Ext(file)) + "_bodies"
+		withSidecar := *document
+		withSidecar.Sidecar = &BodySidecar{Dir: filepath.Join(r.Dir, bodies), URL: bodies}
+		document = &withSidecar
+	}
+
+	html, err := document.RenderHTML()
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := os.MkdirAll(r.Dir, 0755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(r.Dir, file)
+	if err := ioutil.WriteFile(path, []byte(html), 0644); err != nil {
+		return "", err
+	}
+
+	status := -1
+	if len(document.Diagrams) > 0 {
+		status, _ = document.Diagrams[len(document.Diagrams)-1].responseStatus()
+	}
+	entry := ReportIndexEntry{
+		Name:           name,
+		File:           file,
+		Title:          document.Title,
+		DiagramCount:   len(document.Diagrams),
+		StatusCode:     status,
+		BadgeClass:     badgeCSSClass(status),
+		EmbeddedAssets: document.EmbeddedAssets,
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(filepath.Join(r.Dir, strings.TrimSuffix(file, ".html")+reportEntryExt), data); err != nil {
+		return "", err
+	}
+	if err := r.writeIndex(); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+const reportEntryExt = ".report.json"
+
+// indexAttempts is the number of times the index is written before writeIndex gives up on other processes
+// writing reports at the same time
+var indexAttempts = 50
+
+// writeIndex rebuilds index.html from the entries in the directory. Another process may write a report
+// while the index is rebuilt, so the index is rebuilt until it matches the entries read after writing it
+func (r *ReportWriter) writeIndex() error {
+	var written []byte
+	for attempt := 0; attempt <= indexAttempts; attempt++ {
+		entries, err := r.readEntries()
+		if err != nil {
+			return err
+		}
+		index, err := renderIndex(entries)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(index, written) {
+			return nil
+		}
+		if attempt == indexAttempts {
+			break
+		}
+		if err := writeFileAtomic(filepath.Join(r.Dir, "index.html"), index); err != nil {
+			return err
+		}
+		written = index
+	}
+	return fmt.Errorf("index.html did not settle after %d writes while other reports were written", indexAttempts)
+}
+
+func (r *ReportWriter) readEntries() ([]ReportIndexEntry, error) {
+	files, err := filepath.Glob(filepath.Join(r.Dir, "*"+reportEntryExt))
+	if err != nil {
+		return nil, err
+	}
+	var entries []ReportIndexEntry
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var entry ReportIndexEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("%s: %v", file, err)
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, nil
+}
+
+// renderIndex renders the index page. Its stylesheet is inlined when any report inlines its assets, so
+// the index works offline wherever those reports do
+func renderIndex(entries []ReportIndexEntry) ([]byte, error) {
+	embedded := false
+	for _, entry := range entries {
+		embedded = embedded || entry.EmbeddedAssets
+	}
+	stylesheets, _, err := buildAssetModels(embedded)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New("index").Parse(indexTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, reportIndexModel{Entries: entries, Stylesheets: stylesheets}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// writeFileAtomic replaces the file by renaming a temporary file over it, so readers in other processes
+// never see it partly written
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// reportFileName turns a test name such as TestApi/gets_posts into a file name that is safe on every platform
+func reportFileName(name string) string {
+	file := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, name)
+	if file == "" || file == "index" {
+		file = "_" + file
+	}
+	return file + ".html"
+}
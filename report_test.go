@@ -0,0 +1,115 @@
+package sequence
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestReportWriter_WritesDocumentNamedAfterTest(t *testing.T) {
+	dir := t.TempDir()
+
+	path, err := NewReportWriter(dir).Write("TestApi/gets posts", NewDocument().AddTitle("Posts").AddDiagram(aDiagram()))
+
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(dir, "TestApi_gets_posts.html"), path)
+	html, _ := ioutil.ReadFile(path)
+	assert.Contains(t, string(html), "<title>Posts</title>")
+}
+
+func TestReportWriter_WritesIndex(t *testing.T) {
+	dir := t.TempDir()
+	failing := NewDiagram().
+		AddHttpRequest(aRequest()).
+		AddHttpResponse(HttpResponse{Value: &http.Response{StatusCode: http.StatusInternalServerError}})
+
+	_, err := NewReportWriter(dir).Write("TestB", NewDocument().AddTitle("B").AddDiagram(aDiagram()).AddDiagram(failing))
+	assert.Nil(t, err)
+	_, err = NewReportWriter(dir).Write("TestA", NewDocument().AddDiagram(aDiagram()))
+	assert.Nil(t, err)
+
+	index, _ := ioutil.ReadFile(filepath.Join(dir, "index.html"))
+	assert.Contains(t, string(index), `<td><a href="TestA.html">TestA</a></td>
+                <td>1</td>
+                <td><span class="badge badge-success">204</span></td>`)
+	assert.Contains(t, string(index), `<td><a href="TestB.html">B</a></td>
+                <td>2</td>
+                <td><span class="badge badge-danger">500</span></td>`)
+}
+
+func TestReportWriter_InlinesIndexStylesheetWhenReportsEmbedAssets(t *testing.T) {
+	withAssets(t, fakeAssets())
+	dir := t.TempDir()
+
+	_, err := NewReportWriter(dir).Write("TestA", NewDocument().AddDiagram(aDiagram()).UseEmbeddedAssets())
+
+	assert.Nil(t, err)
+	index, _ := ioutil.ReadFile(filepath.Join(dir, "index.html"))
+	assert.Contains(t, string(index), "<style>.bootstrap-css{}</style>")
+	assert.NotContains(t, string(index), "https://")
+}
+
+func TestReportWriter_ReplacesExistingEntry(t *testing.T) {
+	dir := t.TempDir()
+	writer := NewReportWriter(dir)
+
+	writer.Write("TestA", NewDocument().AddTitle("first").AddDiagram(aDiagram()))
+	writer.Write("TestA", NewDocument().AddTitle("second").AddDiagram(aDiagram()))
+
+	index, _ := ioutil.ReadFile(filepath.Join(dir, "index.html"))
+	assert.NotContains(t, string(index), "first")
+	assert.Contains(t, string(index), "second")
+}
+
+func TestReportWriter_KeepsEveryEntryWhenWritersRunConcurrently(t *testing.T) {
+	dir := t.TempDir()
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			// separate writers do not share a lock, like test packages run as separate processes
+			_, err := NewReportWriter(dir).Write(name, NewDocument().AddDiagram(aDiagram()))
+			assert.Nil(t, err)
+		}(fmt.Sprintf("Test%02d", i))
+	}
+	wg.Wait()
+
+	index, _ := ioutil.ReadFile(filepath.Join(dir, "index.html"))
+	for i := 0; i < 20; i++ {
+		assert.Contains(t, string(index), fmt.Sprintf(`<a href="Test%02d.html">`, i))
+	}
+}
+
+func TestReportWriter_ErrorIfIndexDoesNotSettle(t *testing.T) {
+	attempts := indexAttempts
+	indexAttempts = 0
+	t.Cleanup(func() { indexAttempts = attempts })
+
+	_, err := NewReportWriter(t.TempDir()).Write("TestA", NewDocument().AddDiagram(aDiagram()))
+
+	assert.EqualError(t, err, "index.html did not settle after 0 writes while other reports were written")
+}
+
+func TestReportFileName(t *testing.T) {
+	assert.Equal(t, "TestApi_GetPosts_with_id_1_.html", reportFileName("TestApi/GetPosts with id=1?"))
+	assert.Equal(t, "_index.html", reportFileName("index"))
+}
+
+func TestReportWriter_WithFullBodies(t *testing.T) {
+	dir := t.TempDir()
+	document := NewDocument().AddDiagram(aDiagramWithBody("abcdefghij")).LimitBodySize(4)
+
+	path, err := NewReportWriter(dir).WithFullBodies().Write("TestUpload", document)
+
+	assert.Nil(t, err)
+	html, _ := ioutil.ReadFile(path)
+	assert.Contains(t, string(html), `<a href="TestUpload_bodies/diagram1_1.txt">Full body</a>`)
+	body, _ := ioutil.ReadFile(filepath.Join(dir, "TestUpload_bodies", "diagram1_1.txt"))
+	assert.Equal(t, "abcdefghij", string(body))
+	assert.Nil(t, document.Sidecar)
+}
@@ -0,0 +1,264 @@
+package sequence
+
+import (
+	"net"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// ParticipantResolver names participants after the logical services they are, such as posts-service
+// rather than example.com:443. It is applied when a Diagram or Document is rendered or exported, so calls
+// recorded by RecordingTransport and RecordingHandler and events imported from HAR files, traces and JSON
+// recordings are named the same way, and a service gets the same lifeline in every diagram of a report.
+// The recorded events keep their original names
+type ParticipantResolver struct {
+	// Rules are tried in order and the first that matches names the participant
+	Rules []ParticipantRule
+}
+
+// ParticipantRule names the participant a request is sent to. A rule matches when each of its Host,
+// PathPrefix and Header conditions that are set matches
+type ParticipantRule struct {
+	// Host matches the host the request is sent to, with or without its port, using path.Match patterns
+	// such as *.posts.svc.cluster.local. Participants named by a host, such as those imported from HAR
+	// files, are matched too
+	Host string
+	// PathPrefix matches the request path and the paths below it, such as /posts for an API gateway
+	PathPrefix string
+	// Header names a request header that must be present, such as X-Service-Name. When Name is empty the
+	// participant is named by the header's value
+	Header string
+	Name   string
+}
+
+type pendingCall struct {
+	from, to       string
+	source, target string
+}
+
+func NewParticipantResolver() *ParticipantResolver {
+	return &ParticipantResolver{}
+}
+
+func (r *ParticipantResolver) AddRule(rule ParticipantRule) *ParticipantResolver {
+	r.Rules = append(r.Rules, rule)
+	return r
+}
+
+// MapHost names participants reached at hosts matching the pattern
+func (r *ParticipantResolver) MapHost(pattern, name string) *ParticipantResolver {
+	return r.AddRule(ParticipantRule{Host: pattern, Name: name})
+}
+
+// MapPathPrefix names participants reached by requests below the path prefix
+func (r *ParticipantResolver) MapPathPrefix(prefix, name string) *ParticipantResolver {
+	return r.AddRule(ParticipantRule{PathPrefix: prefix, Name: name})
+}
+
+// MapHeader names participants by the value of a request header, such as X-Service-Name
+func (r *ParticipantResolver) MapHeader(header string) *ParticipantResolver {
+	return r.AddRule(ParticipantRule{Header: header})
+}
+
+// Namer returns a ParticipantNamer that applies the resolver when calls are recorded, naming the target
+// after the host when no rule matches
+func (r *ParticipantResolver) Namer(source string) ParticipantNamer {
+	hostNamer := HostNamer(source)
+	return func(req *http.Request) (string, string) {
+		source, target := hostNamer(req)
+		if name, ok := r.resolveRequest(req); ok {
+			target = name
+		}
+		return source, target
+	}
+}
+
+// AddParticipantResolver sets the resolver applied when the diagram is rendered or exported
+func (r *Diagram) AddParticipantResolver(resolver *ParticipantResolver) *Diagram {
+	r.Resolver = resolver
+	return r
+}
+
+// participants returns the declared participants with their IDs resolved like the events
+func (r *Diagram) participants() []Participant {
+	participants, events := r.recorded()
+	if r.Resolver == nil {
+		return participants
+	}
+	return r.Resolver.participants(events, participants)
+}
+
+// AddParticipantResolver sets the resolver applied to every diagram in the document that has no resolver
+// of its own
+func (r *Document) AddParticipantResolver(resolver *ParticipantResolver) *Document {
+	r.Resolver = resolver
+	return r
+}
+
+// resolveRequest names the participant a request is sent to
+func (r *ParticipantResolver) resolveRequest(req *http.Request) (string, bool) {
+	host := req.Host
+	if req.URL != nil && req.URL.Host != "" {
+		host = req.URL.Host
+	}
+	for _, rule := range r.Rules {
+		if rule.Host != "" && !matchHost(rule.Host, host) {
+			continue
+		}
+		if rule.PathPrefix != "" && (req.URL == nil || !matchPathPrefix(rule.PathPrefix, req.URL.Path)) {
+			continue
+		}
+		name := rule.Name
+		if rule.Header != "" {
+			value := req.Header.Get(rule.Header)
+			if value == "" {
+				continue
+			}
+			if name == "" {
+				name = value
+			}
+		}
+		if name != "" {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// resolveName names a participant known only by name, using the rules that only match hosts
+func (r *ParticipantResolver) resolveName(participant string) string {
+	for _, rule := range r.Rules {
+		if rule.Host != "" && rule.PathPrefix == "" && rule.Header == "" && rule.Name != "" && matchHost(rule.Host, participant) {
+			return rule.Name
+		}
+	}
+	return participant
+}
+
+// events returns copies of the built in events with their participants resolved
+func (r *ParticipantResolver) events(events []Event) []Event {
+	resolved, _ := r.resolve(events)
+	return resolved
+}
+
+// participants returns the declared participants with their IDs resolved the same way as the events, so
+// a declaration follows the participant it declares. When several declarations resolve to the same ID
+// the first is kept
+func (r *ParticipantResolver) participants(events []Event, declared []Participant) []Participant {
+	if len(declared) == 0 {
+		return declared
+	}
+	_, renamed := r.resolve(events)
+	seen := map[string]bool{}
+	var participants []Participant
+	for _, participant := range declared {
+		if participant.ID != "" {
+			id := renamed[participant.ID]
+			if id == "" {
+				id = r.resolveName(participant.ID)
+			}
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			participant.ID = id
+		}
+		participants = append(participants, participant)
+	}
+	return participants
+}
+
+// resolve returns copies of the built in events with their participants resolved, along with the names
+// the participants of the events were resolved to. A response is drawn between the participants of the
+// request it answers
+func (r *ParticipantResolver) resolve(events []Event) ([]Event, map[string]string) {
+	resolved := make([]Event, len(events))
+	renamed := map[string]string{}
+	var pending []pendingCall
+	for i, event := range events {
+		resolved[i] = event
+		if event == nil {
+			continue
+		}
+		if note, ok := event.(Note); ok {
+			participants := make([]string, len(note.Participants))
+			for j, name := range note.Participants {
+				if participants[j] = renamed[name]; participants[j] == "" {
+					participants[j] = r.resolveName(name)
+				}
+			}
+			note.Participants = participants
+			resolved[i] = note
+			continue
+		}
+		if isAnnotation(event) {
+			continue
+		}
+
+		from, to := event.From(), event.To()
+		source, target := r.resolveName(from), r.resolveName(to)
+		if event.IsResponse() {
+			for j := len(pending) - 1; j >= 0; j-- {
+				if pending[j].from == to && pending[j].to == from {
+					source, target = pending[j].target, pending[j].source
+					pending = append(pending[:j], pending[j+1:]...)
+					break
+				}
+			}
+		} else {
+			if req, ok := event.(HttpRequest); ok && req.Value != nil {
+				if name, ok := r.resolveRequest(req.Value); ok {
+					target = name
+				}
+			}
+			pending = append(pending, pendingCall{from: from, to: to, source: source, target: target})
+		}
+		renamed[from], renamed[to] = source, target
+		resolved[i] = withParticipants(event, source, target)
+	}
+	return resolved, renamed
+}
+
+// withParticipants returns a copy of a built in event drawn between source and target. Custom event
+// types are returned unchanged
+func withParticipants(event Event, source, target string) Event {
+	switch v := event.(type) {
+	case HttpRequest:
+		v.Source, v.Target = source, target
+		return v
+	case HttpResponse:
+		v.Source, v.Target = source, target
+		return v
+	case MessageRequest:
+		v.Source, v.Target = source, target
+		return v
+	case MessageResponse:
+		v.Source, v.Target = source, target
+		return v
+	case SpanRequest:
+		v.Source, v.Target = source, target
+		return v
+	case SpanResponse:
+		v.Source, v.Target = source, target
+		return v
+	}
+	return event
+}
+
+func matchHost(pattern, host string) bool {
+	pattern, host = strings.ToLower(pattern), strings.ToLower(host)
+	if ok, _ := path.Match(pattern, host); ok {
+		return true
+	}
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		ok, _ := path.Match(pattern, hostname)
+		return ok
+	}
+	return false
+}
+
+func matchPathPrefix(prefix, urlPath string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return prefix == "" || urlPath == prefix || strings.HasPrefix(urlPath, prefix+"/")
+}
@@ -0,0 +1,190 @@
+package sequence
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParticipantResolver_MapHost(t *testing.T) {
+	dsl, err := aResolvedDiagram("http://posts.svc.cluster.local:8080/posts/1").
+		AddParticipantResolver(NewParticipantResolver().MapHost("*.svc.cluster.local", "posts-service")).
+		RenderWebSequenceDSL()
+
+	assert.Nil(t, err)
+	assert.Equal(t, `participant app
+participant "posts-service" as posts_service
+app->posts_service: (1) GET http://posts.svc.cluster.local:8080/posts/1
+posts_service->>app: (2) 200
+`, dsl)
+}
+
+func TestParticipantResolver_MapPathPrefix(t *testing.T) {
+	resolver := NewParticipantResolver().
+		MapPathPrefix("/users", "users-service").
+		MapPathPrefix("/posts", "posts-service")
+
+	mermaid, err := aResolvedDiagram("http://gateway.local/posts/1").AddParticipantResolver(resolver).RenderMermaid()
+	assert.Nil(t, err)
+	assert.Contains(t, mermaid, "app->>posts_service: (1) GET http://gateway.local/posts/1")
+
+	mermaid, err = aResolvedDiagram("http://gateway.local/postscript").AddParticipantResolver(resolver).RenderMermaid()
+	assert.Nil(t, err)
+	assert.Contains(t, mermaid, "app->>gateway_local: (1) GET http://gateway.local/postscript")
+}
+
+func TestParticipantResolver_MapHeader(t *testing.T) {
+	diagram := aResolvedDiagram("http://10.0.0.7/posts/1")
+	diagram.Events[0].(HttpRequest).Value.Header.Set("X-Service-Name", "posts-service")
+
+	plantUML, err := diagram.AddParticipantResolver(NewParticipantResolver().MapHeader("X-Service-Name")).RenderPlantUML()
+
+	assert.Nil(t, err)
+	assert.Contains(t, plantUML, "app -> posts_service : (1) GET http://10.0.0.7/posts/1\nposts_service --> app : (2) 200\n")
+}
+
+func TestParticipantResolver_FirstMatchingRuleWins(t *testing.T) {
+	resolver := NewParticipantResolver().
+		AddRule(ParticipantRule{Host: "gateway.local", PathPrefix: "/posts", Name: "posts-service"}).
+		MapHost("gateway.local", "gateway")
+
+	name, ok := resolver.resolveRequest(aResolvedDiagram("http://gateway.local/posts").Events[0].(HttpRequest).Value)
+	assert.True(t, ok)
+	assert.Equal(t, "posts-service", name)
+
+	name, ok = resolver.resolveRequest(aResolvedDiagram("http://gateway.local/users").Events[0].(HttpRequest).Value)
+	assert.True(t, ok)
+	assert.Equal(t, "gateway", name)
+}
+
+func TestParticipantResolver_KeepsRecordedEvents(t *testing.T) {
+	diagram := aResolvedDiagram("http://posts.local/posts").
+		AddParticipantResolver(NewParticipantResolver().MapHost("posts.local", "posts-service"))
+
+	_, err := diagram.RenderMermaid()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "posts.local", diagram.Events[0].To())
+	assert.Equal(t, "posts.local", diagram.Events[1].From())
+}
+
+func TestParticipantResolver_RenamesNoteParticipants(t *testing.T) {
+	dsl, err := aResolvedDiagram("http://posts.local/posts").
+		AddNote(Note{Position: NoteLeftOf, Participants: []string{"posts.local"}, Text: "cached"}).
+		AddParticipantResolver(NewParticipantResolver().MapHost("posts.local", "posts-service")).
+		RenderWebSequenceDSL()
+
+	assert.Nil(t, err)
+	assert.Contains(t, dsl, "Note left of posts_service: cached\n")
+}
+
+func TestParticipantResolver_ResolvesDeclaredParticipants(t *testing.T) {
+	diagram := aResolvedDiagram("https://example.com:443/posts").
+		AddParticipant(Participant{ID: "example.com:443", Name: "Posts", Kind: ParticipantDatabase}).
+		AddParticipantResolver(NewParticipantResolver().MapHost("example.com", "posts-service"))
+
+	plantUML, err := diagram.RenderPlantUML()
+
+	assert.Nil(t, err)
+	assert.Equal(t, `@startuml
+header 200
+database "Posts" as posts_service
+participant app
+app -> posts_service : (1) GET https://example.com:443/posts
+posts_service --> app : (2) 200
+@enduml
+`, plantUML)
+	assert.Equal(t, "example.com:443", diagram.Participants[0].ID)
+}
+
+func TestParticipantResolver_KeepsFirstDeclarationOfAResolvedParticipant(t *testing.T) {
+	resolver := NewParticipantResolver().MapHost("*.posts.local", "posts-service")
+
+	participants := resolver.participants(nil, []Participant{
+		{ID: "a.posts.local", Name: "A"},
+		{ID: "app"},
+		{ID: "b.posts.local", Name: "B"},
+	})
+
+	assert.Equal(t, []Participant{{ID: "posts-service", Name: "A"}, {ID: "app"}}, participants)
+}
+
+func TestDocument_AddParticipantResolver_NamesParticipantsInEveryDiagram(t *testing.T) {
+	document := NewDocument().
+		AddParticipantResolver(NewParticipantResolver().MapHost("posts.local", "posts-service")).
+		AddDiagram(aResolvedDiagram("http://posts.local/posts")).
+		AddDiagram(aResolvedDiagram("http://posts.local:8080/posts/1")).
+		AddDiagram(aResolvedDiagram("http://users.local/users").
+			AddParticipantResolver(NewParticipantResolver().MapHost("users.local", "users-service")))
+
+	model, err := document.BuildModel()
+
+	assert.Nil(t, err)
+	assert.Contains(t, model.Diagrams[0].WebSequenceDSL, "app->posts_service: (1) GET http://posts.local/posts\n")
+	assert.Contains(t, model.Diagrams[1].WebSequenceDSL, "app->posts_service: (1) GET http://posts.local:8080/posts/1\n")
+	assert.Contains(t, model.Diagrams[2].WebSequenceDSL, "app->users_service: (1) GET http://users.local/users\n")
+}
+
+func TestDocument_AddParticipantResolver_NamesImportedParticipants(t *testing.T) {
+	document, err := DocumentFromHAR(strings.NewReader(harFixture))
+	assert.Nil(t, err)
+
+	model, err := document.
+		AddParticipantResolver(NewParticipantResolver().MapHost("api.example.com", "api")).
+		BuildModel()
+
+	assert.Nil(t, err)
+	assert.Contains(t, model.Diagrams[0].WebSequenceDSL, "participant Posts\nparticipant \"cdn.example.com\" as cdn_example_com\nparticipant api\n")
+	assert.Contains(t, model.Diagrams[0].WebSequenceDSL, "Posts->api: (3) POST https://api.example.com/posts?draft=true\n")
+	assert.Contains(t, model.Diagrams[0].WebSequenceDSL, "api->>Posts: (4) 201 [12ms]\n")
+}
+
+func TestParticipantResolver_AppliesBeforeRedaction(t *testing.T) {
+	diagram := aResolvedDiagram("http://posts.local/posts").
+		AddParticipantResolver(NewParticipantResolver().MapHost("posts.local", "posts-service")).
+		AddRedaction(DefaultRedaction())
+
+	dsl, err := diagram.RenderWebSequenceDSL()
+
+	assert.Nil(t, err)
+	assert.Contains(t, dsl, "app->posts_service: (1) GET http://posts.local/posts\n")
+}
+
+func TestDocument_AddParticipantResolver_RendersHTML(t *testing.T) {
+	html, err := NewDocument().
+		AddParticipantResolver(NewParticipantResolver().MapHost("posts.local", "posts-service")).
+		AddDiagram(aResolvedDiagram("http://posts.local/posts")).
+		RenderHTML()
+
+	assert.Nil(t, err)
+	assert.Contains(t, html, `participant \u0022posts-service\u0022 as posts_service\napp-\u003eposts_service: (1) GET`)
+	assert.NotContains(t, html, "posts.local-")
+}
+
+func TestParticipantResolver_Namer(t *testing.T) {
+	diagram := NewDiagram()
+	transport := NewRecordingTransport(diagram).
+		WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+		})).
+		WithNamer(NewParticipantResolver().MapPathPrefix("/users", "users-service").Namer("posts-api"))
+	client := &http.Client{Transport: transport}
+
+	_, err := client.Get("http://gateway.local/users/1")
+	assert.Nil(t, err)
+	_, err = client.Get("http://gateway.local/health")
+	assert.Nil(t, err)
+
+	assert.Equal(t, "posts-api", diagram.Events[0].From())
+	assert.Equal(t, "users-service", diagram.Events[0].To())
+	assert.Equal(t, "users-service", diagram.Events[1].From())
+	assert.Equal(t, "gateway.local", diagram.Events[2].To())
+}
+
+func aResolvedDiagram(url string) *Diagram {
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	return NewDiagram().
+		AddHttpRequest(HttpRequest{Source: "app", Target: req.URL.Host, Value: req}).
+		AddHttpResponse(HttpResponse{Source: req.URL.Host, Target: "app", Value: &http.Response{StatusCode: http.StatusOK}})
+}
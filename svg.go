@@ -0,0 +1,436 @@
+package sequence
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"unicode/utf8"
+)
+
+const (
+	svgMargin          = 20
+	svgCharWidth       = 8
+	svgFontSize        = 14
+	svgBoxPadding      = 10
+	svgBoxHeight       = 36
+	svgRowHeight       = 40
+	svgParticipantGap  = 40
+	svgSelfArrowWidth  = 30
+	svgSelfArrowHeight = 20
+	svgNoteHeight      = 28
+	svgNoteGap         = 5
+	svgFrameInset      = 6
+)
+
+type svgFrame int
+
+const (
+	svgFrameNone svgFrame = iota
+	svgFrameStart
+	svgFrameBranch
+	svgFrameEnd
+)
+
+type svgRow struct {
+	source      string
+	target      string
+	description string
+	response    bool
+	// note is set for notes, which are drawn beside source or over source to target
+	note    NotePosition
+	divider bool
+	// frame is set for the rows that start, branch and end groups, which are drawn as a frame around
+	// the rows between them
+	frame svgFrame
+	kind  GroupKind
+}
+
+func (r svgRow) isMessage() bool {
+	return r.note == "" && !r.divider && r.frame == svgFrameNone
+}
+
+// SVGDiagram lays out a sequence diagram and draws it as a static SVG image, so diagrams can be shown
+// where JavaScript is not available such as PR comments, emails and PDFs
+type SVGDiagram struct {
+	rows         []svgRow
+	count        int
+	participants participantAliases
+	// idPrefix prefixes the ids of the elements the image defines, so several images on one page do not
+	// refer to each other's elements
+	idPrefix string
+}
+
+func (r *SVGDiagram) AddParticipant(participant Participant) {
+	r.participants.declare(participant)
+}
+
+func (r *SVGDiagram) AddRequestRow(source, target, description string) {
+	r.addRow(source, target, description, false)
+}
+
+func (r *SVGDiagram) AddResponseRow(source, target, description string) {
+	r.addRow(source, target, description, true)
+}
+
+func (r *SVGDiagram) addRow(source, target, description string, response bool) {
+	r.participants.alias(source)
+	r.participants.alias(target)
+	r.count += 1
+	r.rows = append(r.rows, svgRow{
+		source:      source,
+		target:      target,
+		description: fmt.Sprintf("(%d) %s", r.count, description),
+		response:    response,
+	})
+}
+
+func (r *SVGDiagram) AddNote(position NotePosition, participants []string, text string) {
+	for _, name := range participants {
+		r.participants.alias(name)
+	}
+	r.rows = append(r.rows, svgRow{
+		source:      participants[0],
+		target:      participants[len(participants)-1],
+		description: text,
+		note:        position,
+	})
+}
+
+func (r *SVGDiagram) AddDivider(text string) {
+	r.rows = append(r.rows, svgRow{description: text, divider: true})
+}
+
+func (r *SVGDiagram) AddGroupStart(kind GroupKind, label string) {
+	r.rows = append(r.rows, svgRow{description: label, frame: svgFrameStart, kind: kind})
+}
+
+func (r *SVGDiagram) AddGroupBranch(kind GroupKind, label string) {
+	r.rows = append(r.rows, svgRow{description: label, frame: svgFrameBranch, kind: kind})
+}
+
+func (r *SVGDiagram) AddGroupEnd(kind GroupKind) {
+	r.rows = append(r.rows, svgRow{frame: svgFrameEnd, kind: kind})
+}
+
+func (r *SVGDiagram) ToString() string {
+	names := r.participants.names
+	columns := r.layoutColumns()
+
+	width := svgMargin
+	if len(names) > 0 {
+		last := names[len(names)-1]
+		width = columns[last] + svgBoxWidth(r.participants.participant(last).Name)/2 + svgMargin
+		for _, row := range r.rows {
+			if row.isMessage() && row.source == row.target && row.source == last {
+				width += svgSelfArrowWidth + svgTextWidth(row.description)
+			}
+		}
+	}
+	depth := 0
+	for _, row := range r.rows {
+		switch row.frame {
+		case svgFrameStart:
+			if required := 2*svgMargin + 2*depth*svgFrameInset + row.frameTitleWidth(); required > width {
+				width = required
+			}
+			depth++
+		case svgFrameBranch:
+			if required := 2*svgMargin + 2*(depth-1)*svgFrameInset + row.frameTitleWidth(); required > width {
+				width = required
+			}
+		case svgFrameEnd:
+			depth--
+		}
+		if row.note != "" {
+			x, w := row.noteBox(columns)
+			if x+w+svgMargin > width {
+				width = x + w + svgMargin
+			}
+		}
+		if row.divider && svgTextWidth(row.description)+2*svgBoxPadding+2*svgMargin > width {
+			width = svgTextWidth(row.description) + 2*svgBoxPadding + 2*svgMargin
+		}
+	}
+	lifelineTop := svgMargin + svgBoxHeight
+	lifelineBottom := lifelineTop + (len(r.rows)+1)*svgRowHeight
+	height := lifelineBottom + svgBoxHeight + svgMargin
+
+	var out bytes.Buffer
+	out.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="%d">`+"\n",
+		width, height, width, height, svgFontSize))
+	arrowhead := r.idPrefix + "arrowhead"
+	out.WriteString(fmt.Sprintf(`<defs><marker id="%s" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="8" markerHeight="8" orient="auto">`+
+		`<path d="M0,0 L10,5 L0,10 z" fill="black"/></marker></defs>`+"\n", arrowhead))
+
+	for _, name := range names {
+		x := columns[name]
+		out.WriteString(fmt.Sprintf(`<line class="lifeline" x1="%d" y1="%d" x2="%d" y2="%d" stroke="black" stroke-dasharray="2,2"/>`+"\n",
+			x, lifelineTop, x, lifelineBottom))
+		writeSVGParticipant(&out, r.participants.participant(name), x, svgMargin)
+		writeSVGParticipant(&out, r.participants.participant(name), x, lifelineBottom)
+	}
+
+	// frames holds the top of each group that has started but not ended
+	var frames []int
+	for i, row := range r.rows {
+		y := lifelineTop + (i+1)*svgRowHeight
+		left, right := svgMargin+len(frames)*svgFrameInset, width-svgMargin-len(frames)*svgFrameInset
+		switch row.frame {
+		case svgFrameStart:
+			frames = append(frames, y-svgRowHeight/2)
+			writeSVGFrameTitle(&out, string(row.kind), row.description, left, y-svgRowHeight/2)
+			continue
+		case svgFrameBranch:
+			left, right = left-svgFrameInset, right+svgFrameInset
+			out.WriteString(fmt.Sprintf(`<line class="group" x1="%d" y1="%d" x2="%d" y2="%d" stroke="black" stroke-dasharray="6,4"/>`+"\n",
+				left, y-svgRowHeight/2, right, y-svgRowHeight/2))
+			writeSVGFrameTitle(&out, branchKeyword(row.kind), row.description, left, y-svgRowHeight/2)
+			continue
+		case svgFrameEnd:
+			left, right = left-svgFrameInset, right+svgFrameInset
+			top := frames[len(frames)-1]
+			frames = frames[:len(frames)-1]
+			out.WriteString(fmt.Sprintf(`<rect class="group" x="%d" y="%d" width="%d" height="%d" fill="none" stroke="black"/>`+"\n",
+				left, top, right-left, y-svgRowHeight/2-top))
+			continue
+		}
+		if row.note != "" {
+			x, w := row.noteBox(columns)
+			out.WriteString(fmt.Sprintf(`<rect class="note" x="%d" y="%d" width="%d" height="%d" fill="#ffffcc" stroke="black"/>`+"\n",
+				x, y-svgNoteHeight+4, w, svgNoteHeight))
+			out.WriteString(fmt.Sprintf(`<text x="%d" y="%d" text-anchor="middle">%s</text>`+"\n",
+				x+w/2, y-5, escapeXML(row.description)))
+			continue
+		}
+		if row.divider {
+			w := svgTextWidth(row.description) + 2*svgBoxPadding
+			out.WriteString(fmt.Sprintf(`<line class="divider" x1="%d" y1="%d" x2="%d" y2="%d" stroke="black" stroke-dasharray="8,4"/>`+"\n",
+				svgMargin, y-10, width-svgMargin, y-10))
+			out.WriteString(fmt.Sprintf(`<rect class="divider" x="%d" y="%d" width="%d" height="%d" fill="white" stroke="black"/>`+"\n",
+				width/2-w/2, y-svgNoteHeight+4, w, svgNoteHeight))
+			out.WriteString(fmt.Sprintf(`<text x="%d" y="%d" text-anchor="middle">%s</text>`+"\n",
+				width/2, y-5, escapeXML(row.description)))
+			continue
+		}
+
+		dash := ""
+		if row.response {
+			dash = ` stroke-dasharray="6,4"`
+		}
+
+		x1, x2 := columns[row.source], columns[row.target]
+		if x1 == x2 {
+			out.WriteString(fmt.Sprintf(`<polyline class="message" points="%d,%d %d,%d %d,%d %d,%d" fill="none" stroke="black"%s marker-end="url(#%s)"/>`+"\n",
+				x1, y-svgSelfArrowHeight, x1+svgSelfArrowWidth, y-svgSelfArrowHeight, x1+svgSelfArrowWidth, y, x1, y, dash, arrowhead))
+			out.WriteString(fmt.Sprintf(`<text x="%d" y="%d">%s</text>`+"\n",
+				x1+svgSelfArrowWidth+5, y-svgSelfArrowHeight/2+5, escapeXML(row.description)))
+			continue
+		}
+
+		out.WriteString(fmt.Sprintf(`<line class="message" x1="%d" y1="%d" x2="%d" y2="%d" stroke="black"%s marker-end="url(#%s)"/>`+"\n",
+			x1, y, x2, y, dash, arrowhead))
+		out.WriteString(fmt.Sprintf(`<text x="%d" y="%d" text-anchor="middle">%s</text>`+"\n",
+			(x1+x2)/2, y-5, escapeXML(row.description)))
+	}
+
+	out.WriteString("</svg>\n")
+	return out.String()
+}
+
+// layoutColumns returns the x coordinate of each participant's lifeline. Lifelines are spaced so that
+// participant boxes do not overlap and every message label fits between the lifelines it connects
+func (r *SVGDiagram) layoutColumns() map[string]int {
+	names := r.participants.names
+	index := map[string]int{}
+	positions := make([]int, len(names))
+	for i, name := range names {
+		index[name] = i
+		if i == 0 {
+			positions[i] = svgMargin + svgBoxWidth(r.participants.participant(name).Name)/2
+			continue
+		}
+		positions[i] = positions[i-1] + svgBoxWidth(r.participants.participant(names[i-1]).Name)/2 + svgParticipantGap +
+			svgBoxWidth(r.participants.participant(name).Name)/2
+	}
+
+	// require moves the lifelines from index to onwards right until they are at least distance right of
+	// the lifeline at index from. When from is -1 every lifeline is moved right until there is room for a
+	// note between the left margin and the first lifeline
+	require := func(from, to, distance int) {
+		start := svgMargin - svgNoteGap
+		if from >= 0 {
+			start = positions[from]
+		} else {
+			to = 0
+		}
+		if deficit := distance - (positions[to] - start); deficit > 0 {
+			for i := to; i < len(positions); i++ {
+				positions[i] += deficit
+			}
+		}
+	}
+
+	for _, row := range r.rows {
+		if row.divider || row.frame != svgFrameNone {
+			continue
+		}
+		from, to := index[row.source], index[row.target]
+		if row.note != "" {
+			if from > to {
+				from, to = to, from
+			}
+			w := svgTextWidth(row.description) + 2*svgBoxPadding
+			switch {
+			case row.note == NoteLeftOf:
+				require(from-1, from, w+2*svgNoteGap)
+			case row.note == NoteRightOf && to+1 < len(names):
+				require(to, to+1, w+2*svgNoteGap)
+			case row.note == NoteOver && from == to:
+				require(from-1, from, w/2+svgNoteGap)
+				if to+1 < len(names) {
+					require(to, to+1, w/2+svgNoteGap)
+				}
+			case row.note == NoteOver:
+				require(from, to, w-2*svgBoxPadding)
+			}
+			continue
+		}
+
+		required := svgTextWidth(row.description) + 2*svgBoxPadding
+		if from == to {
+			// messages to self are drawn to the right of the lifeline
+			if to+1 == len(names) {
+				continue
+			}
+			to++
+			required += svgSelfArrowWidth
+		}
+		if from > to {
+			from, to = to, from
+		}
+		require(from, to, required)
+	}
+
+	columns := map[string]int{}
+	for i, name := range names {
+		columns[name] = positions[i]
+	}
+	return columns
+}
+
+// noteBox returns the left edge and width of a note. Notes over several participants span their lifelines
+func (r svgRow) noteBox(columns map[string]int) (int, int) {
+	w := svgTextWidth(r.description) + 2*svgBoxPadding
+	x1, x2 := columns[r.source], columns[r.target]
+	if x1 > x2 {
+		x1, x2 = x2, x1
+	}
+	switch r.note {
+	case NoteLeftOf:
+		return x1 - svgNoteGap - w, w
+	case NoteRightOf:
+		return x2 + svgNoteGap, w
+	}
+	if span := x2 - x1 + 2*svgBoxPadding; span > w {
+		w = span
+	}
+	return (x1+x2)/2 - w/2, w
+}
+
+// frameTitleWidth returns the width taken by the keyword and label at the top of a group or branch
+func (r svgRow) frameTitleWidth() int {
+	keyword := string(r.kind)
+	if r.frame == svgFrameBranch {
+		keyword = branchKeyword(r.kind)
+	}
+	width := svgTextWidth(keyword) + 2*svgBoxPadding
+	if r.description != "" {
+		width += svgTextWidth("["+r.description+"]") + 2*svgBoxPadding
+	}
+	return width
+}
+
+// writeSVGFrameTitle draws the keyword of a group or branch in a tab at the top left of its frame,
+// followed by its label
+func writeSVGFrameTitle(out *bytes.Buffer, keyword, label string, x, y int) {
+	w := svgTextWidth(keyword) + 2*svgBoxPadding
+	out.WriteString(fmt.Sprintf(`<path class="group" d="M%d,%d h%d v%d l-6,6 h-%d z" fill="#eeeeee" stroke="black"/>`+"\n",
+		x, y, w, svgNoteHeight-6, w-6))
+	out.WriteString(fmt.Sprintf(`<text x="%d" y="%d" font-weight="bold">%s</text>`+"\n",
+		x+svgBoxPadding, y+svgFontSize+4, escapeXML(keyword)))
+	if label != "" {
+		out.WriteString(fmt.Sprintf(`<text x="%d" y="%d">%s</text>`+"\n",
+			x+w+svgBoxPadding, y+svgFontSize+4, escapeXML("["+label+"]")))
+	}
+}
+
+// writeSVGParticipant draws a participant's box centred on x. Actors have rounded corners, databases are
+// drawn as cylinders, queues with a closed end and external participants with a dashed outline
+func writeSVGParticipant(out *bytes.Buffer, participant Participant, x, y int) {
+	w := svgBoxWidth(participant.Name)
+	class := "participant"
+	if participant.Kind != "" {
+		class += " " + string(participant.Kind)
+	}
+	fill := "white"
+	if participant.Color != "" {
+		fill = escapeXML(participant.Color)
+	}
+
+	switch participant.Kind {
+	case ParticipantDatabase:
+		out.WriteString(fmt.Sprintf(`<path class="%s" d="M%d,%d a%d,6 0 0,0 %d,0 a%d,6 0 0,0 -%d,0 v%d a%d,6 0 0,0 %d,0 v-%d" fill="%s" stroke="black"/>`+"\n",
+			class, x-w/2, y+6, w/2, w, w/2, w, svgBoxHeight-12, w/2, w, svgBoxHeight-12, fill))
+	case ParticipantQueue:
+		out.WriteString(fmt.Sprintf(`<rect class="%s" x="%d" y="%d" width="%d" height="%d" fill="%s" stroke="black"/>`+"\n",
+			class, x-w/2, y, w, svgBoxHeight, fill))
+		out.WriteString(fmt.Sprintf(`<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="black"/>`+"\n",
+			x+w/2-6, y, x+w/2-6, y+svgBoxHeight))
+	default:
+		extra := ""
+		if participant.Kind == ParticipantActor {
+			extra = fmt.Sprintf(` rx="%d"`, svgBoxHeight/2)
+		} else if participant.Kind == ParticipantExternal {
+			extra = ` stroke-dasharray="4,2"`
+		}
+		out.WriteString(fmt.Sprintf(`<rect class="%s" x="%d" y="%d" width="%d" height="%d"%s fill="%s" stroke="black"/>`+"\n",
+			class, x-w/2, y, w, svgBoxHeight, extra, fill))
+	}
+	out.WriteString(fmt.Sprintf(`<text x="%d" y="%d" text-anchor="middle">%s</text>`+"\n",
+		x, y+svgBoxHeight/2+svgFontSize/2-2, escapeXML(participant.Name)))
+}
+
+func svgBoxWidth(name string) int {
+	return svgTextWidth(name) + 2*svgBoxPadding
+}
+
+func svgTextWidth(text string) int {
+	return utf8.RuneCountInString(text) * svgCharWidth
+}
+
+func escapeXML(text string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(text))
+	return buf.String()
+}
+
+// RenderSVG lays out the diagram in Go and renders it as a static SVG image
+func (r *Diagram) RenderSVG() (string, error) {
+	return r.renderSVG("")
+}
+
+// renderSVG renders the diagram as a static SVG image whose element ids start with idPrefix
+func (r *Diagram) renderSVG(idPrefix string) (string, error) {
+	svg := &SVGDiagram{idPrefix: idPrefix}
+	if err := r.writeDSL(svg); err != nil {
+		return "", err
+	}
+	return svg.ToString(), nil
+}
+
+// UseStaticSVG embeds diagrams rendered by RenderSVG into the HTML instead of drawing them in the
+// browser with JavaScript
+func (r *Document) UseStaticSVG() *Document {
+	r.StaticSVG = true
+	return r
+}
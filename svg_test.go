@@ -0,0 +1,83 @@
+package sequence
+
+import (
+	"encoding/xml"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestSVGDiagram_DrawsParticipantsAndLifelines(t *testing.T) {
+	svg := SVGDiagram{}
+	svg.AddRequestRow("A", "B", "request")
+	svg.AddResponseRow("B", "A", "response")
+
+	out := svg.ToString()
+
+	assert.Equal(t, 4, strings.Count(out, `<rect class="participant"`))
+	assert.Equal(t, 2, strings.Count(out, `<line class="lifeline"`))
+	assert.Equal(t, 2, strings.Count(out, ">A</text>"))
+	assert.Equal(t, 2, strings.Count(out, ">B</text>"))
+}
+
+func TestSVGDiagram_DrawsSolidRequestsAndDashedResponses(t *testing.T) {
+	svg := SVGDiagram{}
+	svg.AddRequestRow("A", "B", "request")
+	svg.AddResponseRow("B", "A", "response")
+
+	out := svg.ToString()
+
+	assert.Contains(t, out, `<line class="message" x1="34" y1="96" x2="150" y2="96" stroke="black" marker-end="url(#arrowhead)"/>`)
+	assert.Contains(t, out, `<line class="message" x1="150" y1="136" x2="34" y2="136" stroke="black" stroke-dasharray="6,4" marker-end="url(#arrowhead)"/>`)
+	assert.Contains(t, out, ">(1) request</text>")
+	assert.Contains(t, out, ">(2) response</text>")
+}
+
+func TestSVGDiagram_SpacesLifelinesToFitLabels(t *testing.T) {
+	label := strings.Repeat("x", 50)
+	svg := SVGDiagram{}
+	svg.AddRequestRow("A", "B", label)
+
+	columns := svg.layoutColumns()
+
+	assert.True(t, columns["B"]-columns["A"] >= svgTextWidth("(1) "+label))
+}
+
+func TestSVGDiagram_DrawsMessagesToSelf(t *testing.T) {
+	svg := SVGDiagram{}
+	svg.AddRequestRow("A", "A", "loop")
+
+	assert.Contains(t, svg.ToString(), `<polyline class="message"`)
+}
+
+func TestSVGDiagram_IsValidXML(t *testing.T) {
+	svg := SVGDiagram{}
+	svg.AddRequestRow("<A>", "B&C", `GET /?a=1&b="2"`)
+
+	decoder := xml.NewDecoder(strings.NewReader(svg.ToString()))
+	var err error
+	for err == nil {
+		_, err = decoder.Token()
+	}
+
+	assert.Equal(t, "EOF", err.Error())
+}
+
+func TestDocument_RenderHTML_EmbedsStaticSVG(t *testing.T) {
+	html, err := NewDocument().AddDiagram(aDiagram()).UseStaticSVG().RenderHTML()
+
+	assert.Nil(t, err)
+	assert.Contains(t, html, `<svg xmlns="http://www.w3.org/2000/svg"`)
+	assert.NotContains(t, html, "Diagram.parse")
+}
+
+func TestDocument_RenderHTML_GivesEachStaticSVGItsOwnArrowhead(t *testing.T) {
+	html, err := NewDocument().AddDiagram(aDiagram()).AddDiagram(aDiagram()).UseStaticSVG().RenderHTML()
+
+	assert.Nil(t, err)
+	assert.Contains(t, html, `<marker id="d0-arrowhead"`)
+	assert.Contains(t, html, `marker-end="url(#d0-arrowhead)"`)
+	assert.Contains(t, html, `<marker id="d1-arrowhead"`)
+	assert.Contains(t, html, `marker-end="url(#d1-arrowhead)"`)
+	assert.NotContains(t, html, `"arrowhead"`)
+}
@@ -4,14 +4,12 @@ const t = `<!DOCTYPE html>
 <html lang="en">
 <head>
     <meta charset="utf-8">
-    <link rel="stylesheet" href="https://stackpath.bootstrapcdn.com/bootstrap/4.1.2/css/bootstrap.min.css">
-    <link rel="stylesheet" href="https://cdnjs.cloudflare.com/ajax/libs/highlight.js/9.12.0/styles/github.min.css" />
-    <script src="https://cdnjs.cloudflare.com/ajax/libs/underscore.js/1.8.3/underscore-min.js"></script>
-    <script src="https://cdnjs.cloudflare.com/ajax/libs/raphael/2.2.7/raphael.min.js"></script>
-    <script src="https://bramp.github.io/js-sequence-diagrams/js/sequence-diagram-min.js"></script>
-    <script src="https://code.jquery.com/jquery-3.3.1.slim.min.js"></script>
-    <script src="https://cdnjs.cloudflare.com/ajax/libs/popper.js/1.14.3/umd/popper.min.js"></script>
-    <script src="https://stackpath.bootstrapcdn.com/bootstrap/4.1.2/js/bootstrap.min.js"></script>
+    {{- range .Stylesheets }}
+    {{ if .Inline }}<style>{{ .InlineCSS }}</style>{{ else }}<link rel="stylesheet" href="{{ .URL }}">{{ end }}
+    {{- end }}
+    {{- range .Scripts }}
+    {{ if .Inline }}<script>{{ .InlineJS }}</script>{{ else }}<script src="{{ .URL }}"></script>{{ end }}
+    {{- end }}
     <title>{{.Title}}</title>
 </head>
 <body>
@@ -20,10 +18,11 @@ const t = `<!DOCTYPE html>
 <div class="container-fluid">
     <h1>{{ $d.Title }}</h1>
     <span class="{{ $d.BadgeClass }}">{{ $d.StatusCode }}</span>
+    {{ if $d.TotalTime }}<span class="badge badge-secondary">{{ $d.TotalTime }}</span>{{ end }}
     <p class="lead">{{ $d.SubTitle }}</p>
     <div class="card text-center">
         <div class="card-body">
-            <div id="d" class="justify-content-center"></div>
+            <div id="d{{ $i }}" class="justify-content-center">{{ $d.SVG }}</div>
         </div>
     </div>
     <br><br>
@@ -33,24 +32,34 @@ const t = `<!DOCTYPE html>
         <tr>
             <th scope="col">#</th>
             <th scope="col">Payload</th>
+            <th scope="col">Latency</th>
         </tr>
         </thead>
         <tbody>
         {{ range $li, $le := $d.LogEntries }}
+            {{ if $le.Annotation }}
+            <tr class="table-info">
+                <th scope="row"></th>
+                <td colspan="2"><em>{{ $le.Annotation }}{{ if $le.Header }}:</em> {{ $le.Header }}{{ else }}</em>{{ end }}</td>
+            </tr>
+            {{ else }}
             <tr>
-                <th scope="row">{{ inc $li }}</th>
+                <th scope="row">{{ arrowNumber $d.LogEntries $li }}</th>
                 <td>
                     <pre>{{ $le.Header }}</pre>
-                    {{if $le.Body }}<pre><code class="json">{{ $le.Body }}</code></pre>{{end}}
+                    {{if $le.Body }}<pre><code class="{{ if $le.Language }}{{ $le.Language }}{{ else }}nohighlight{{ end }}">{{ $le.Body }}</code></pre>{{end}}
+                    {{if $le.BodyURL }}<a href="{{ $le.BodyURL }}">Full body</a>{{end}}
                 </td>
+                <td>{{ $le.Latency }}</td>
             </tr>
+            {{ end }}
         {{ end }}
         </tbody>
     </table>
 </div>
-<script>
-    Diagram.parse("{{ $d.WebSequenceDSL }}").drawSVG("d", {theme: 'simple', 'font-size': 14});
-</script>
+{{ if not $d.SVG }}<script>
+    Diagram.parse("{{ $d.WebSequenceDSL }}").drawSVG("d{{ $i }}", {theme: 'simple', 'font-size': 14});
+</script>{{ end }}
 <style>
     body {
         padding-top: 2rem;
@@ -59,7 +68,46 @@ const t = `<!DOCTYPE html>
 </style>
 {{ end }}
 {{if $.MetaJSON }}<script type="application/json" id="metaJson">{{ $.MetaJSON }}</script>{{end}}
-<script src="https://cdn.jsdelivr.net/gh/highlightjs/cdn-release@9.13.1/build/highlight.min.js"></script>
 <script>hljs.initHighlightingOnLoad();</script>
 </body>
 </html>`
+
+const indexTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="utf-8">
+    {{- range .Stylesheets }}
+    {{ if .Inline }}<style>{{ .InlineCSS }}</style>{{ else }}<link rel="stylesheet" href="{{ .URL }}">{{ end }}
+    {{- end }}
+    <title>Sequence diagram reports</title>
+</head>
+<body>
+<div class="container-fluid">
+    <h1>Sequence diagram reports</h1>
+    <table class="table">
+        <thead>
+        <tr>
+            <th scope="col">Report</th>
+            <th scope="col">Diagrams</th>
+            <th scope="col">Status</th>
+        </tr>
+        </thead>
+        <tbody>
+        {{ range .Entries }}
+            <tr>
+                <td><a href="{{ .File }}">{{ if .Title }}{{ .Title }}{{ else }}{{ .Name }}{{ end }}</a></td>
+                <td>{{ .DiagramCount }}</td>
+                <td><span class="{{ .BadgeClass }}">{{ .StatusCode }}</span></td>
+            </tr>
+        {{ end }}
+        </tbody>
+    </table>
+</div>
+<style>
+    body {
+        padding-top: 2rem;
+        padding-bottom: 2rem;
+    }
+</style>
+</body>
+</html>`
@@ -0,0 +1,54 @@
+package sequence
+
+import "time"
+
+// eventDuration returns how long an event took when it records both its start and end
+func eventDuration(event Event) (time.Duration, bool) {
+	timer, ok := event.(Timer)
+	if !ok {
+		return 0, false
+	}
+	start, end := timer.Timing()
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0, false
+	}
+	return end.Sub(start), true
+}
+
+// totalTime returns the time from the earliest to the latest time recorded by the events, or an empty
+// string when fewer than two distinct times are known
+func totalTime(events []Event) string {
+	var first, last time.Time
+	for _, event := range events {
+		timer, ok := event.(Timer)
+		if !ok {
+			continue
+		}
+		start, end := timer.Timing()
+		for _, t := range []time.Time{start, end} {
+			if t.IsZero() {
+				continue
+			}
+			if first.IsZero() || t.Before(first) {
+				first = t
+			}
+			if last.IsZero() || t.After(last) {
+				last = t
+			}
+		}
+	}
+	if first.IsZero() || !last.After(first) {
+		return ""
+	}
+	return formatDuration(last.Sub(first))
+}
+
+// formatDuration rounds durations to three significant figures, such as 2.01s, 153ms or 42µs
+func formatDuration(d time.Duration) string {
+	for unit := time.Duration(1); unit < time.Hour; unit *= 10 {
+		if d < 1000*unit {
+			return d.Round(unit).String()
+		}
+	}
+	return d.Round(time.Second).String()
+}
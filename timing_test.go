@@ -0,0 +1,64 @@
+package sequence
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func aTimedDiagram() *Diagram {
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	return NewDiagram().
+		AddMessageRequest(MessageRequest{Source: "cli", Target: "app", Header: "get", Start: start}).
+		AddMessageRequest(MessageRequest{Source: "app", Target: "db", Header: "query", Start: start.Add(10 * time.Millisecond)}).
+		AddMessageResponse(MessageResponse{Source: "db", Target: "app", Header: "rows", Start: start.Add(10 * time.Millisecond), End: start.Add(2010 * time.Millisecond)}).
+		AddMessageResponse(MessageResponse{Source: "app", Target: "cli", Header: "ok", Start: start, End: start.Add(2153 * time.Millisecond)})
+}
+
+func TestDiagram_DrawsDurationsOnArrows(t *testing.T) {
+	dsl, err := aTimedDiagram().RenderWebSequenceDSL()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "cli->app: (1) get\napp->db: (2) query\ndb->>app: (3) rows [2s]\napp->>cli: (4) ok [2.15s]\n", dsl)
+}
+
+func TestDiagram_BuildModel_SetsLatencyAndTotalTime(t *testing.T) {
+	model, err := aTimedDiagram().BuildModel()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "2.15s", model.TotalTime)
+	assert.Equal(t, "", model.LogEntries[0].Latency)
+	assert.Equal(t, "2s", model.LogEntries[2].Latency)
+	assert.Equal(t, "2.15s", model.LogEntries[3].Latency)
+}
+
+func TestDiagram_BuildModel_NoTotalTimeWithoutTimes(t *testing.T) {
+	model, err := aDiagram().BuildModel()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "", model.TotalTime)
+}
+
+func TestDocument_RenderHTML_ShowsLatency(t *testing.T) {
+	html, err := NewDocument().AddDiagram(aTimedDiagram()).RenderHTML()
+
+	assert.Nil(t, err)
+	assert.Contains(t, html, `<span class="badge badge-secondary">2.15s</span>`)
+	assert.Contains(t, html, `<th scope="col">Latency</th>`)
+	assert.Contains(t, html, `<td>2s</td>`)
+}
+
+func TestDocument_RenderMarkdown_ShowsLatency(t *testing.T) {
+	markdown, err := NewDocument().AddDiagram(aTimedDiagram()).RenderMarkdown()
+
+	assert.Nil(t, err)
+	assert.Contains(t, markdown, "**Total time:** 2.15s\n")
+	assert.Contains(t, markdown, "**(4)** 2.15s\n")
+}
+
+func TestFormatDuration(t *testing.T) {
+	assert.Equal(t, "2.01s", formatDuration(2013*time.Millisecond))
+	assert.Equal(t, "153ms", formatDuration(153400*time.Microsecond))
+	assert.Equal(t, "42.3µs", formatDuration(42345*time.Nanosecond))
+	assert.Equal(t, "1m5s", formatDuration(65*time.Second))
+}
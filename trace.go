@@ -0,0 +1,272 @@
+package sequence
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// traceClient names the caller of root server spans, which have no parent to name it after
+const traceClient = "client"
+
+type (
+	// SpanRequest is a call between two services reconstructed from a trace
+	SpanRequest struct {
+		Source     string
+		Target     string
+		Name       string
+		TraceID    string
+		SpanID     string
+		Start      time.Time
+		Attributes map[string]string
+	}
+
+	// SpanResponse is the reply to a SpanRequest, drawn when the span ends
+	SpanResponse struct {
+		Source  string
+		Target  string
+		Name    string
+		TraceID string
+		SpanID  string
+		Start   time.Time
+		End     time.Time
+		Status  int
+	}
+
+	spanKind int
+
+	// span is the format independent view of a trace span shared by the trace importers
+	span struct {
+		traceID    string
+		spanID     string
+		parentID   string
+		service    string
+		name       string
+		kind       spanKind
+		start      time.Time
+		end        time.Time
+		attributes map[string]string
+	}
+
+	timedEvent struct {
+		at    time.Time
+		event Event
+	}
+)
+
+const (
+	spanKindInternal spanKind = iota
+	spanKindServer
+	spanKindClient
+	spanKindProducer
+	spanKindConsumer
+)
+
+func (r SpanRequest) From() string { return r.Source }
+
+func (r SpanRequest) To() string { return r.Target }
+
+func (r SpanRequest) IsResponse() bool { return false }
+
+func (r SpanRequest) Label() string {
+	method := firstAttribute(r.Attributes, "http.method", "http.request.method")
+	url := firstAttribute(r.Attributes, "http.url", "url.full", "http.target", "url.path", "http.path")
+	if method != "" && url != "" {
+		return fmt.Sprintf("%s %s", method, url)
+	}
+	return r.Name
+}
+
+// LogEntry lists the span identifiers and its attributes sorted by key
+func (r SpanRequest) LogEntry() (LogEntry, error) {
+	header := fmt.Sprintf("%s\ntrace %s span %s\nstarted %s", r.Name, r.TraceID, r.SpanID, r.Start.UTC().Format(time.RFC3339Nano))
+
+	keys := make([]string, 0, len(r.Attributes))
+	for key := range r.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var body bytes.Buffer
+	for _, key := range keys {
+		body.WriteString(fmt.Sprintf("%s: %s\n", key, r.Attributes[key]))
+	}
+	return LogEntry{Header: header, Body: body.String()}, nil
+}
+
+func (r SpanRequest) Timing() (time.Time, time.Time) { return r.Start, time.Time{} }
+
+func (r SpanResponse) From() string { return r.Source }
+
+func (r SpanResponse) To() string { return r.Target }
+
+func (r SpanResponse) IsResponse() bool { return true }
+
+func (r SpanResponse) StatusCode() int { return r.Status }
+
+func (r SpanResponse) Timing() (time.Time, time.Time) { return r.Start, r.End }
+
+func (r SpanResponse) Label() string {
+	if r.Status > 0 {
+		return strconv.Itoa(r.Status)
+	}
+	return r.Name
+}
+
+func (r SpanResponse) LogEntry() (LogEntry, error) {
+	header := fmt.Sprintf("%s\ntrace %s span %s\nended %s", r.Name, r.TraceID, r.SpanID, r.End.UTC().Format(time.RFC3339Nano))
+	if r.Status > 0 {
+		header = fmt.Sprintf("%s\nstatus %d", header, r.Status)
+	}
+	return LogEntry{Header: header}, nil
+}
+
+// diagramFromSpans draws each client span as a call to the service of its server child span, and each
+// server span without a client parent as a call from its parent's service. Internal spans are not drawn.
+// Events are ordered by the time they happened
+func diagramFromSpans(spans []span) *Diagram {
+	byID := map[string]span{}
+	children := map[string][]span{}
+	for _, s := range spans {
+		byID[s.spanID] = s
+		if s.parentID != "" {
+			children[s.parentID] = append(children[s.parentID], s)
+		}
+	}
+
+	var timeline []timedEvent
+	for _, s := range spans {
+		var source, target string
+		switch s.kind {
+		case spanKindClient, spanKindProducer:
+			source, target = s.service, peerService(s, children[s.spanID])
+		case spanKindServer, spanKindConsumer:
+			parent, ok := byID[s.parentID]
+			if ok && (parent.kind == spanKindClient || parent.kind == spanKindProducer) {
+				continue
+			}
+			source, target = traceClient, s.service
+			if ok {
+				source = parent.service
+			}
+		default:
+			continue
+		}
+
+		timeline = append(timeline,
+			timedEvent{at: s.start, event: SpanRequest{
+				Source:     source,
+				Target:     target,
+				Name:       s.name,
+				TraceID:    s.traceID,
+				SpanID:     s.spanID,
+				Start:      s.start,
+				Attributes: s.attributes,
+			}},
+			timedEvent{at: s.end, event: SpanResponse{
+				Source:  target,
+				Target:  source,
+				Name:    s.name,
+				TraceID: s.traceID,
+				SpanID:  s.spanID,
+				Start:   s.start,
+				End:     s.end,
+				Status:  spanStatus(s, children[s.spanID]),
+			}})
+	}
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].at.Before(timeline[j].at)
+	})
+
+	diagram := NewDiagram()
+	if root := rootSpan(spans); root != nil {
+		diagram.AddTitle(root.name).AddSubTitle(root.traceID)
+	}
+	for _, e := range timeline {
+		diagram.AddEvent(e.event)
+	}
+	return diagram
+}
+
+// peerService names the service a client span calls, preferring the service of the server span it caused
+func peerService(client span, children []span) string {
+	for _, child := range children {
+		if child.kind == spanKindServer || child.kind == spanKindConsumer {
+			return child.service
+		}
+	}
+	if peer := firstAttribute(client.attributes, "peer.service", "server.address", "net.peer.name", "messaging.destination", "db.system"); peer != "" {
+		return peer
+	}
+	return "unknown"
+}
+
+func spanStatus(s span, children []span) int {
+	if status, err := strconv.Atoi(firstAttribute(s.attributes, "http.status_code", "http.response.status_code")); err == nil {
+		return status
+	}
+	for _, child := range children {
+		if status, err := strconv.Atoi(firstAttribute(child.attributes, "http.status_code", "http.response.status_code")); err == nil {
+			return status
+		}
+	}
+	return -1
+}
+
+func rootSpan(spans []span) *span {
+	ids := map[string]bool{}
+	for _, s := range spans {
+		ids[s.spanID] = true
+	}
+	for i, s := range spans {
+		if s.parentID == "" || !ids[s.parentID] {
+			return &spans[i]
+		}
+	}
+	return nil
+}
+
+// spanKindFromName parses the span kind names used by Jaeger and Zipkin
+func spanKindFromName(kind string) spanKind {
+	switch strings.ToLower(kind) {
+	case "server":
+		return spanKindServer
+	case "client":
+		return spanKindClient
+	case "producer":
+		return spanKindProducer
+	case "consumer":
+		return spanKindConsumer
+	default:
+		return spanKindInternal
+	}
+}
+
+// documentFromSpans returns a document with one diagram per trace, in the order each trace first appears
+func documentFromSpans(spans []span) *Document {
+	var order []string
+	traces := map[string][]span{}
+	for _, s := range spans {
+		if _, ok := traces[s.traceID]; !ok {
+			order = append(order, s.traceID)
+		}
+		traces[s.traceID] = append(traces[s.traceID], s)
+	}
+
+	document := NewDocument()
+	for _, id := range order {
+		document.AddDiagram(diagramFromSpans(traces[id]))
+	}
+	return document
+}
+
+func firstAttribute(attributes map[string]string, keys ...string) string {
+	for _, key := range keys {
+		if value, ok := attributes[key]; ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,40 @@
+package sequence
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSpanRequest_Label(t *testing.T) {
+	assert.Equal(t, "GET /posts", SpanRequest{Name: "span", Attributes: map[string]string{"http.request.method": "GET", "url.path": "/posts"}}.Label())
+	assert.Equal(t, "span", SpanRequest{Name: "span", Attributes: map[string]string{"http.method": "GET"}}.Label())
+}
+
+func TestSpanResponse_Label(t *testing.T) {
+	assert.Equal(t, "404", SpanResponse{Name: "span", Status: 404}.Label())
+	assert.Equal(t, "span", SpanResponse{Name: "span", Status: -1}.Label())
+}
+
+func TestDiagramFromSpans_NamesUnknownPeers(t *testing.T) {
+	start := time.Unix(0, 0)
+	diagram := diagramFromSpans([]span{
+		{spanID: "1", service: "app", name: "call", kind: spanKindClient, start: start, end: start.Add(time.Second)},
+	})
+
+	assert.Equal(t, "unknown", diagram.Events[0].To())
+	assert.Equal(t, "app", diagram.Events[1].To())
+}
+
+func TestDiagramFromSpans_DrawsServerSpansFromParentService(t *testing.T) {
+	start := time.Unix(0, 0)
+	diagram := diagramFromSpans([]span{
+		{spanID: "1", service: "scheduler", name: "tick", kind: spanKindInternal, start: start, end: start.Add(3 * time.Second)},
+		{spanID: "2", parentID: "1", service: "worker", name: "job", kind: spanKindConsumer, start: start.Add(time.Second), end: start.Add(2 * time.Second)},
+	})
+
+	assert.Len(t, diagram.Events, 2)
+	assert.Equal(t, "scheduler", diagram.Events[0].From())
+	assert.Equal(t, "worker", diagram.Events[0].To())
+	assert.Equal(t, "tick", diagram.Title)
+}
@@ -0,0 +1,95 @@
+package sequence
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// ParticipantNamer returns the participants an outbound request is drawn between
+type ParticipantNamer func(req *http.Request) (source, target string)
+
+// HostNamer names the caller source and the target after the host the request is sent to
+func HostNamer(source string) ParticipantNamer {
+	return func(req *http.Request) (string, string) {
+		if req.URL != nil && req.URL.Host != "" {
+			return source, req.URL.Host
+		}
+		return source, req.Host
+	}
+}
+
+// RecordingTransport is an http.RoundTripper that records every call made through it as an HttpRequest
+// and HttpResponse on a Diagram. The request body is buffered before it is sent. The HttpResponse is added
+// when the response headers arrive, while the body is streamed to the caller and recorded as it is read.
+// The recorded body is attached once the caller reads it to the end or closes it, so a body the caller
+// never reads is recorded as empty
+type RecordingTransport struct {
+	// Transport performs the request. http.DefaultTransport is used when nil
+	Transport http.RoundTripper
+	// Diagram receives the recorded events
+	Diagram *Diagram
+	// Namer names the participants of each call. Defaults to HostNamer("app")
+	Namer ParticipantNamer
+}
+
+func NewRecordingTransport(diagram *Diagram) *RecordingTransport {
+	return &RecordingTransport{Diagram: diagram}
+}
+
+// WithTransport sets the transport wrapped by the recorder
+func (r *RecordingTransport) WithTransport(transport http.RoundTripper) *RecordingTransport {
+	r.Transport = transport
+	return r
+}
+
+// WithNamer sets the function used to name the participants of each call
+func (r *RecordingTransport) WithNamer(namer ParticipantNamer) *RecordingTransport {
+	r.Namer = namer
+	return r
+}
+
+func (r *RecordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	namer := r.Namer
+	if namer == nil {
+		namer = HostNamer("app")
+	}
+	source, target := namer(req)
+
+	var err error
+	recordedReq, outReq := req.Clone(req.Context()), req.Clone(req.Context())
+	recordedReq.Body, outReq.Body, err = drainBody(req.Body)
+	if err != nil {
+		// a RoundTripper must close the request body, even when it fails
+		req.Body.Close()
+		return nil, err
+	}
+	start := time.Now()
+	r.Diagram.AddHttpRequest(HttpRequest{Source: source, Target: target, Value: recordedReq, Start: start})
+
+	res, err := r.transport().RoundTrip(outReq)
+	if err != nil {
+		r.Diagram.AddMessageResponse(MessageResponse{Source: target, Target: source, Header: err.Error(), Start: start, End: time.Now()})
+		return nil, err
+	}
+
+	recordedRes := *res
+	recordedRes.Request = recordedReq
+	r.Diagram.AddHttpResponse(HttpResponse{Source: target, Target: source, Value: &recordedRes, Start: start, End: time.Now()})
+	if res.Body == nil || res.Body == http.NoBody {
+		return res, nil
+	}
+	recordedRes.Body = http.NoBody
+	res.Body = newRecordingBody(res.Body, func(body []byte) {
+		recordedRes.Body = ioutil.NopCloser(bytes.NewReader(body))
+	})
+	return res, nil
+}
+
+func (r *RecordingTransport) transport() http.RoundTripper {
+	if r.Transport == nil {
+		return http.DefaultTransport
+	}
+	return r.Transport
+}
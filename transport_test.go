@@ -0,0 +1,205 @@
+package sequence
+
+import (
+	"bytes"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordingTransport_RecordsRequestAndResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(append([]byte("echo "), body...))
+	}))
+	defer server.Close()
+	diagram := NewDiagram()
+	client := &http.Client{Transport: NewRecordingTransport(diagram)}
+
+	res, err := client.Post(server.URL+"/posts", "text/plain", bytes.NewBufferString("hello"))
+
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(res.Body)
+	assert.Equal(t, "echo hello", string(body))
+	host := res.Request.URL.Host
+	model, err := diagram.BuildModel()
+	assert.Nil(t, err)
+	assert.Len(t, diagram.Events, 2)
+	assert.Equal(t, "app", diagram.Events[0].From())
+	assert.Equal(t, host, diagram.Events[0].To())
+	assert.Equal(t, host, diagram.Events[1].From())
+	assert.Equal(t, "app", diagram.Events[1].To())
+	assert.Equal(t, "hello", model.LogEntries[0].Body)
+	assert.Equal(t, "echo hello", model.LogEntries[1].Body)
+	assert.Equal(t, http.StatusCreated, model.StatusCode)
+}
+
+func TestRecordingTransport_UsesNamer(t *testing.T) {
+	diagram := NewDiagram()
+	transport := NewRecordingTransport(diagram).
+		WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+		})).
+		WithNamer(func(req *http.Request) (string, string) {
+			return "posts-api", "users-service"
+		})
+
+	_, err := (&http.Client{Transport: transport}).Get("http://users.internal/users/1")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "posts-api", diagram.Events[0].From())
+	assert.Equal(t, "users-service", diagram.Events[0].To())
+}
+
+func TestRecordingTransport_RecordsTransportErrors(t *testing.T) {
+	diagram := NewDiagram()
+	transport := NewRecordingTransport(diagram).
+		WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		}))
+
+	_, err := (&http.Client{Transport: transport}).Get("http://example.com/posts")
+
+	assert.Error(t, err)
+	assert.Len(t, diagram.Events, 2)
+	response := diagram.Events[1].(MessageResponse)
+	assert.Equal(t, "example.com", response.Source)
+	assert.Equal(t, "app", response.Target)
+	assert.Equal(t, "connection refused", response.Header)
+	assert.False(t, response.End.Before(response.Start))
+}
+
+func TestRecordingTransport_RecordsTiming(t *testing.T) {
+	diagram := NewDiagram()
+	transport := NewRecordingTransport(diagram).
+		WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			time.Sleep(5 * time.Millisecond)
+			return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader("ok"))}, nil
+		}))
+
+	before := time.Now()
+	response, err := (&http.Client{Transport: transport}).Get("http://example.com/posts")
+
+	assert.Nil(t, err)
+	response.Body.Close()
+	req, res := diagram.Events[0].(HttpRequest), diagram.Events[1].(HttpResponse)
+	assert.False(t, req.Start.Before(before))
+	assert.Equal(t, req.Start, res.Start)
+	duration, ok := eventDuration(res)
+	assert.True(t, ok)
+	assert.True(t, duration >= 5*time.Millisecond)
+}
+
+func TestRecordingTransport_StreamsResponseBody(t *testing.T) {
+	upstream, write := io.Pipe()
+	diagram := NewDiagram()
+	transport := NewRecordingTransport(diagram).
+		WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: upstream}, nil
+		}))
+	go write.Write([]byte("first "))
+
+	res, err := (&http.Client{Transport: transport}).Get("http://example.com/events")
+
+	assert.Nil(t, err)
+	chunk := make([]byte, 6)
+	_, err = io.ReadFull(res.Body, chunk)
+	assert.Nil(t, err)
+	assert.Equal(t, "first ", string(chunk))
+	assert.Len(t, diagram.Events, 2)
+
+	go func() {
+		write.Write([]byte("second"))
+		write.Close()
+	}()
+	rest, err := ioutil.ReadAll(res.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "second", string(rest))
+	model, err := diagram.BuildModel()
+	assert.Nil(t, err)
+	assert.Equal(t, "first second", model.LogEntries[1].Body)
+}
+
+func TestRecordingTransport_RecordsResponseWhenBodyIsClosed(t *testing.T) {
+	diagram := NewDiagram()
+	transport := NewRecordingTransport(diagram).
+		WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(strings.NewReader("unread"))}, nil
+		}))
+
+	res, err := (&http.Client{Transport: transport}).Get("http://example.com/posts")
+	assert.Nil(t, err)
+	res.Body.Close()
+	res.Body.Close()
+
+	assert.Len(t, diagram.Events, 2)
+	assert.Equal(t, http.StatusOK, diagram.Events[1].(HttpResponse).Value.StatusCode)
+	model, err := diagram.BuildModel()
+	assert.Nil(t, err)
+	assert.Equal(t, "", model.LogEntries[1].Body)
+}
+
+func TestRecordingTransport_RecordsResponseWhenBodyIsNeverRead(t *testing.T) {
+	diagram := NewDiagram()
+	transport := NewRecordingTransport(diagram).
+		WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusCreated, Body: ioutil.NopCloser(strings.NewReader("unread"))}, nil
+		}))
+
+	_, err := (&http.Client{Transport: transport}).Post("http://example.com/posts", "application/json", strings.NewReader("{}"))
+	diagram.AddHttpResponse(HttpResponse{Source: "app", Target: "consumer", Value: &http.Response{StatusCode: http.StatusNoContent}})
+
+	assert.Nil(t, err)
+	assert.Len(t, diagram.Events, 3)
+	assert.Equal(t, "example.com", diagram.Events[1].From())
+	assert.Equal(t, http.StatusCreated, diagram.Events[1].(HttpResponse).Value.StatusCode)
+	status, _ := diagram.responseStatus()
+	assert.Equal(t, http.StatusNoContent, status)
+}
+
+func TestHostNamer_FallsBackToRequestHost(t *testing.T) {
+	req := &http.Request{URL: &url.URL{Path: "/posts"}, Host: "example.com"}
+
+	source, target := HostNamer("app")(req)
+
+	assert.Equal(t, "app", source)
+	assert.Equal(t, "example.com", target)
+}
+
+func TestRecordingTransport_ClosesRequestBodyIfItCannotBeRead(t *testing.T) {
+	body := &failingBody{}
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com/posts", body)
+
+	_, err := NewRecordingTransport(NewDiagram()).RoundTrip(req)
+
+	assert.EqualError(t, err, "read failed")
+	assert.True(t, body.closed)
+}
+
+type failingBody struct {
+	closed bool
+}
+
+func (r *failingBody) Read(p []byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+func (r *failingBody) Close() error {
+	r.closed = true
+	return nil
+}
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
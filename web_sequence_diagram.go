@@ -3,11 +3,19 @@ package sequence
 import (
 	"bytes"
 	"fmt"
+	"strings"
 )
 
 type WebSequenceDiagram struct {
-	data  bytes.Buffer
-	count int
+	data         dslRows
+	count        int
+	participants participantAliases
+}
+
+// AddParticipant declares a participant. Once any participant is declared or has a name that needs an
+// alias, every participant is listed in order before the messages
+func (r *WebSequenceDiagram) AddParticipant(participant Participant) {
+	r.participants.declare(participant)
 }
 
 func (r *WebSequenceDiagram) AddRequestRow(source, target, description string) {
@@ -20,14 +28,104 @@ func (r *WebSequenceDiagram) AddResponseRow(source, target, description string)
 
 func (r *WebSequenceDiagram) addRow(operation, source, target, description string) {
 	r.count += 1
-	r.data.WriteString(fmt.Sprintf("%s%s%s: (%d) %s\n",
-		source,
+	r.data.add(fmt.Sprintf("%s%s%s: (%d) %s\n",
+		r.participants.alias(source),
 		operation,
-		target,
+		r.participants.alias(target),
 		r.count,
-		description))
+		escapeWebSequence(description)))
+}
+
+func (r *WebSequenceDiagram) AddNote(position NotePosition, participants []string, text string) {
+	aliases := make([]string, len(participants))
+	for i, name := range participants {
+		aliases[i] = r.participants.alias(name)
+	}
+	r.data.add(fmt.Sprintf("Note %s %s: %s\n", position, strings.Join(aliases, ","), escapeWebSequence(text)))
+}
+
+// AddDivider draws the divider as a note over every participant, since js-sequence-diagrams has no dividers
+func (r *WebSequenceDiagram) AddDivider(text string) {
+	r.data.addSpanning(fmt.Sprintf("== %s ==", escapeWebSequence(text)))
+}
+
+// AddGroupStart draws the start of a group as a note over every participant, since js-sequence-diagrams
+// has no group fragments. Branches and ends of groups are drawn the same way
+func (r *WebSequenceDiagram) AddGroupStart(kind GroupKind, label string) {
+	r.data.addSpanning(groupTitle(string(kind), escapeWebSequence(label)))
+}
+
+func (r *WebSequenceDiagram) AddGroupBranch(kind GroupKind, label string) {
+	r.data.addSpanning(groupTitle(branchKeyword(kind), escapeWebSequence(label)))
+}
+
+func (r *WebSequenceDiagram) AddGroupEnd(kind GroupKind) {
+	r.data.addSpanning(fmt.Sprintf("end %s", kind))
 }
 
 func (r *WebSequenceDiagram) ToString() string {
-	return r.data.String()
+	var out bytes.Buffer
+	names := r.participants.names
+	if r.declaresParticipants() {
+		for _, name := range names {
+			participant := r.participants.participant(name)
+			if alias := r.participants.alias(name); alias == participant.Name {
+				out.WriteString(fmt.Sprintf("participant %s\n", alias))
+			} else {
+				out.WriteString(fmt.Sprintf("participant %s as %s\n", quoteWebSequence(participant.Name), alias))
+			}
+		}
+	}
+	r.data.write(&out, func(text string) string {
+		switch len(names) {
+		case 0:
+			return ""
+		case 1:
+			return fmt.Sprintf("Note over %s: %s\n", r.participants.alias(names[0]), text)
+		}
+		return fmt.Sprintf("Note over %s,%s: %s\n", r.participants.alias(names[0]), r.participants.alias(names[len(names)-1]), text)
+	})
+	return out.String()
+}
+
+// declaresParticipants reports whether the participants must be listed, either because the diagram
+// declares them or because js-sequence-diagrams does not allow characters such as ':' in a name so
+// messages refer to it by an alias
+func (r *WebSequenceDiagram) declaresParticipants() bool {
+	if len(r.participants.declared) > 0 {
+		return true
+	}
+	for _, name := range r.participants.names {
+		if r.participants.alias(name) != name {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderWebSequenceDSL renders the diagram in the js-sequence-diagrams syntax drawn by the HTML report
+func (r *Diagram) RenderWebSequenceDSL() (string, error) {
+	wsd := &WebSequenceDiagram{}
+	if err := r.writeDSL(wsd); err != nil {
+		return "", err
+	}
+	return wsd.ToString(), nil
+}
+
+// RenderWebSequenceDSL renders every diagram of the document in the js-sequence-diagrams syntax, separated
+// by a blank line
+func (r *Document) RenderWebSequenceDSL() (string, error) {
+	return r.renderEach((*Diagram).RenderWebSequenceDSL)
+}
+
+// quoteWebSequence quotes a participant name so js-sequence-diagrams reads it whole. Quoted names
+// cannot contain quotes or line breaks, so those are replaced
+func quoteWebSequence(name string) string {
+	return `"` + strings.NewReplacer(`"`, "'", "\r\n", " ", "\n", " ").Replace(name) + `"`
+}
+
+// escapeWebSequence keeps multi line text on a single js-sequence-diagrams line using its \n escape, so
+// text cannot end a statement and start another
+func escapeWebSequence(text string) string {
+	return strings.NewReplacer("\r\n", `\n`, "\r", `\n`, "\n", `\n`).Replace(text)
 }
@@ -16,3 +16,47 @@ func TestWebSequenceDiagram_GeneratesDSL(t *testing.T) {
 
 	assert.Equal(t, "A->B: (1) request1\nB->C: (2) request2\nC->>B: (3) response1\nB->>A: (4) response2\n", dsl)
 }
+
+func TestWebSequenceDiagram_EscapesLineBreaks(t *testing.T) {
+	wsd := WebSequenceDiagram{}
+	wsd.AddRequestRow("a", "b", "GET /\nb->a: injected")
+	wsd.AddNote(NoteOver, []string{"b"}, "line1\nb->a: injected")
+	wsd.AddDivider("retry\r\nb->a: injected")
+	wsd.AddGroupStart(GroupAlt, "ok\nb->a: injected")
+
+	dsl := wsd.ToString()
+
+	assert.Equal(t, `a->b: (1) GET /\nb->a: injected
+Note over b: line1\nb->a: injected
+Note over a,b: == retry\nb->a: injected ==
+Note over a,b: alt [ok\nb->a: injected]
+`, dsl)
+}
+
+func TestDiagram_RenderWebSequenceDSL(t *testing.T) {
+	diagram := NewDiagram().
+		AddMessageRequest(MessageRequest{Source: "A", Target: "B", Header: "request"}).
+		AddMessageResponse(MessageResponse{Source: "B", Target: "A", Header: "response"})
+
+	dsl, err := diagram.RenderWebSequenceDSL()
+
+	assert.Nil(t, err)
+	assert.Equal(t, "A->B: (1) request\nB->>A: (2) response\n", dsl)
+}
+
+func TestDocument_RenderWebSequenceDSL_AppliesDocumentResolver(t *testing.T) {
+	document := NewDocument().
+		AddParticipantResolver(NewParticipantResolver().MapHost("posts.local", "posts")).
+		AddDiagram(aResolvedDiagram("http://posts.local/posts")).
+		AddDiagram(aResolvedDiagram("http://posts.local/posts/1"))
+
+	dsl, err := document.RenderWebSequenceDSL()
+
+	assert.Nil(t, err)
+	assert.Equal(t, `app->posts: (1) GET http://posts.local/posts
+posts->>app: (2) 200
+
+app->posts: (1) GET http://posts.local/posts/1
+posts->>app: (2) 200
+`, dsl)
+}
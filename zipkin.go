@@ -0,0 +1,82 @@
+package sequence
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Zipkin v2 JSON types, see https://zipkin.io/zipkin-api/#/default/post_spans
+type (
+	zipkinSpan struct {
+		TraceID        string            `json:"traceId"`
+		ID             string            `json:"id"`
+		ParentID       string            `json:"parentId"`
+		Name           string            `json:"name"`
+		Kind           string            `json:"kind"`
+		Timestamp      int64             `json:"timestamp"`
+		Duration       int64             `json:"duration"`
+		LocalEndpoint  zipkinEndpoint    `json:"localEndpoint"`
+		RemoteEndpoint zipkinEndpoint    `json:"remoteEndpoint"`
+		Tags           map[string]string `json:"tags"`
+	}
+
+	zipkinEndpoint struct {
+		ServiceName string `json:"serviceName"`
+	}
+)
+
+// DocumentFromZipkin reads a Zipkin v2 JSON export, a list of spans, and returns a document with one
+// diagram per trace
+func DocumentFromZipkin(r io.Reader) (*Document, error) {
+	var export []zipkinSpan
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("invalid Zipkin trace: %v", err)
+	}
+	if len(export) == 0 {
+		return nil, fmt.Errorf("Zipkin trace has no spans")
+	}
+
+	clients := map[string]bool{}
+	for _, s := range export {
+		if s.Kind == "CLIENT" {
+			clients[s.TraceID+s.ID] = true
+		}
+	}
+
+	var spans []span
+	for _, s := range export {
+		converted := s.toSpan()
+		// a server span shares its id with the client span that caused it when B3 propagation is used
+		if s.Kind == "SERVER" && clients[s.TraceID+s.ID] {
+			converted.parentID = s.ID
+			converted.spanID = s.ID + "-server"
+		}
+		spans = append(spans, converted)
+	}
+	return documentFromSpans(spans), nil
+}
+
+func (r zipkinSpan) toSpan() span {
+	attributes := map[string]string{}
+	for key, value := range r.Tags {
+		attributes[key] = value
+	}
+	if r.RemoteEndpoint.ServiceName != "" {
+		attributes["peer.service"] = r.RemoteEndpoint.ServiceName
+	}
+
+	start := time.Unix(0, r.Timestamp*int64(time.Microsecond)).UTC()
+	return span{
+		traceID:    r.TraceID,
+		spanID:     r.ID,
+		parentID:   r.ParentID,
+		service:    r.LocalEndpoint.ServiceName,
+		name:       r.Name,
+		kind:       spanKindFromName(r.Kind),
+		start:      start,
+		end:        start.Add(time.Duration(r.Duration) * time.Microsecond),
+		attributes: attributes,
+	}
+}
@@ -0,0 +1,61 @@
+package sequence
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+const zipkinFixture = `[
+  {
+    "traceId": "t1", "id": "1", "name": "post /checkout", "kind": "SERVER",
+    "timestamp": 1544712660000000, "duration": 1000000,
+    "localEndpoint": {"serviceName": "frontend"},
+    "tags": {"http.method": "POST", "http.path": "/checkout", "http.status_code": "201"}
+  },
+  {
+    "traceId": "t1", "id": "2", "parentId": "1", "name": "post", "kind": "CLIENT",
+    "timestamp": 1544712660100000, "duration": 600000,
+    "localEndpoint": {"serviceName": "frontend"},
+    "remoteEndpoint": {"serviceName": "payments"},
+    "tags": {"http.method": "POST", "http.path": "/charge"}
+  },
+  {
+    "traceId": "t1", "id": "2", "parentId": "1", "name": "post /charge", "kind": "SERVER", "shared": true,
+    "timestamp": 1544712660200000, "duration": 400000,
+    "localEndpoint": {"serviceName": "payments"},
+    "tags": {"http.status_code": "202"}
+  },
+  {
+    "traceId": "t1", "id": "3", "parentId": "1", "name": "publish", "kind": "PRODUCER",
+    "timestamp": 1544712660800000, "duration": 10000,
+    "localEndpoint": {"serviceName": "frontend"},
+    "remoteEndpoint": {"serviceName": "kafka"}
+  }
+]`
+
+func TestDocumentFromZipkin(t *testing.T) {
+	document, err := DocumentFromZipkin(strings.NewReader(zipkinFixture))
+
+	assert.Nil(t, err)
+	assert.Len(t, document.Diagrams, 1)
+	var rows []string
+	for _, e := range document.Diagrams[0].Events {
+		rows = append(rows, e.From()+" -> "+e.To()+": "+e.Label())
+	}
+	assert.Equal(t, []string{
+		"client -> frontend: POST /checkout",
+		"frontend -> payments: POST /charge",
+		"payments -> frontend: 202",
+		"frontend -> kafka: publish",
+		"kafka -> frontend: publish",
+		"frontend -> client: 201",
+	}, rows)
+}
+
+func TestDocumentFromZipkin_ErrorIfInvalid(t *testing.T) {
+	_, err := DocumentFromZipkin(strings.NewReader(`{}`))
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid Zipkin trace")
+}